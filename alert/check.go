@@ -0,0 +1,80 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/johnDorian/clatest/client"
+)
+
+//Errors the rules which couldn't be checked or notified and why
+type Errors map[string]error
+
+func (e Errors) Error() string {
+	var names []string
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var messages []string
+	for _, name := range names {
+		messages = append(messages, fmt.Sprintf("%v: %v", name, e[name]))
+	}
+	return strings.Join(messages, "\n")
+}
+
+//Check evaluates each rule on the series of its country, and notifies the rules which fire and hadn't already.
+//Rules which no longer fire are cleared from the state so they can fire again. A rule whose notification
+//failed isn't marked as fired, so it is tried again next time. The alerts which were notified are returned
+func Check(ctx context.Context, rules []Rule, series map[string]client.TimeSeries, state *State, notify func(context.Context, Alert) error) ([]Alert, error) {
+	var notified []Alert
+	failed := Errors{}
+	for _, rule := range rules {
+		ts, ok := series[rule.Country]
+		if !ok {
+			failed[rule.Name] = fmt.Errorf("no data for %v", rule.Country)
+			continue
+		}
+		alert, fires, err := rule.Evaluate(ts)
+		if err != nil {
+			failed[rule.Name] = err
+			continue
+		}
+		if !fires {
+			delete(state.Fired, rule.Name)
+			continue
+		}
+		if _, ok := state.Fired[rule.Name]; ok {
+			continue
+		}
+		err = notify(ctx, alert)
+		if err != nil {
+			failed[rule.Name] = err
+			continue
+		}
+		state.Fired[rule.Name] = alert.Date
+		notified = append(notified, alert)
+	}
+	if len(failed) > 0 {
+		return notified, failed
+	}
+	return notified, nil
+}
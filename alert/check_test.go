@@ -0,0 +1,65 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/johnDorian/clatest/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	rules := []Rule{
+		{Name: "cases", Country: "Australia", Metric: "new_cases", Above: float(12), Command: "x"},
+		{Name: "deaths", Country: "Australia", Metric: "deaths", Above: float(100), Command: "x"},
+		{Name: "nz", Country: "New Zealand", Metric: "cases", Above: float(1), Command: "x"},
+	}
+	series := map[string]client.TimeSeries{"Australia": days(100, 113)}
+	state := &State{Fired: map[string]time.Time{"deaths": time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)}}
+	var notified []string
+	notify := func(ctx context.Context, a Alert) error {
+		notified = append(notified, a.Rule.Name)
+		return nil
+	}
+
+	alerts, err := Check(context.Background(), rules, series, state, notify)
+	assert.EqualError(err, "nz: no data for New Zealand")
+	assert.Len(alerts, 1)
+	assert.Equal([]string{"cases"}, notified)
+	// deaths no longer fires so can fire again
+	assert.Equal(map[string]time.Time{"cases": time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)}, state.Fired)
+
+	// still firing, so not notified again
+	alerts, err = Check(context.Background(), rules[:1], series, state, notify)
+	assert.NoError(err)
+	assert.Empty(alerts)
+	assert.Equal([]string{"cases"}, notified)
+
+	// a failed notification is tried again next time
+	state = &State{Fired: map[string]time.Time{}}
+	_, err = Check(context.Background(), rules[:1], series, state, func(ctx context.Context, a Alert) error {
+		return fmt.Errorf("boom")
+	})
+	assert.EqualError(err, "cases: boom")
+	assert.Empty(state.Fired)
+}
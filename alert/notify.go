@@ -0,0 +1,122 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+//payload the json posted to a webhook
+type payload struct {
+	Rule     string  `json:"rule"`
+	Country  string  `json:"country"`
+	Date     string  `json:"date"`
+	Metric   string  `json:"metric"`
+	Value    float64 `json:"value"`
+	Previous float64 `json:"previous,omitempty"`
+	Text     string  `json:"text"` // The message, named so Slack and Mattermost show it as is
+}
+
+//Notifier posts alerts to the webhook and runs the command of their rule
+type Notifier struct {
+	Client *http.Client
+	Stdout io.Writer // Where the output of the commands goes
+	Stderr io.Writer
+}
+
+//NewNotifier a notifier using client for the webhooks, with the output of the commands on stdout and stderr
+func NewNotifier(client *http.Client) *Notifier {
+	return &Notifier{Client: client, Stdout: os.Stdout, Stderr: os.Stderr}
+}
+
+//Notify sends the alert to the webhook and the command of its rule, trying both before returning an error
+func (n *Notifier) Notify(ctx context.Context, a Alert) error {
+	var webhookErr, commandErr error
+	if a.Rule.Webhook != "" {
+		webhookErr = n.post(ctx, a)
+	}
+	if a.Rule.Command != "" {
+		commandErr = n.run(ctx, a)
+	}
+	if webhookErr != nil {
+		return webhookErr
+	}
+	return commandErr
+}
+
+func (n *Notifier) post(ctx context.Context, a Alert) error {
+	b, err := json.Marshal(payload{
+		Rule:     a.Rule.Name,
+		Country:  a.Country,
+		Date:     a.Date.Format("2006-01-02"),
+		Metric:   a.Rule.Metric,
+		Value:    a.Value,
+		Previous: a.Previous,
+		Text:     a.String(),
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.Rule.Webhook, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %v", resp.Status)
+	}
+	return nil
+}
+
+//run runs the command through the shell, with the alert in CLATEST_ environment variables
+func (n *Notifier) run(ctx context.Context, a Alert) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", a.Rule.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", a.Rule.Command)
+	}
+	cmd.Env = append(os.Environ(),
+		"CLATEST_RULE="+a.Rule.Name,
+		"CLATEST_COUNTRY="+a.Country,
+		"CLATEST_DATE="+a.Date.Format("2006-01-02"),
+		"CLATEST_METRIC="+a.Rule.Metric,
+		"CLATEST_VALUE="+formatFloat(a.Value),
+		"CLATEST_MESSAGE="+a.String(),
+	)
+	cmd.Stdout, cmd.Stderr = n.Stdout, n.Stderr
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("command failed: %v", err)
+	}
+	return nil
+}
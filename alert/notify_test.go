@@ -0,0 +1,92 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifyWebhook(t *testing.T) {
+	assert := assert.New(t)
+
+	var received []payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(http.MethodPost, r.Method)
+		assert.Equal("application/json", r.Header.Get("Content-Type"))
+		var p payload
+		b, _ := ioutil.ReadAll(r.Body)
+		assert.NoError(json.Unmarshal(b, &p))
+		received = append(received, p)
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	alert := Alert{
+		Rule:     Rule{Name: "rising", Metric: "new_cases_7d_avg", Rise: float(20), Webhook: server.URL + "/hook"},
+		Country:  "Australia",
+		Date:     time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC),
+		Value:    13,
+		Previous: 10,
+	}
+	n := NewNotifier(server.Client())
+	assert.NoError(n.Notify(context.Background(), alert))
+	assert.Equal([]payload{{
+		Rule:     "rising",
+		Country:  "Australia",
+		Date:     "2021-03-25",
+		Metric:   "new_cases_7d_avg",
+		Value:    13,
+		Previous: 10,
+		Text:     "rising: new_cases_7d_avg of Australia rose 30% on the week before to 13 on 2021-03-25",
+	}}, received)
+
+	alert.Rule.Webhook = server.URL + "/broken"
+	assert.EqualError(n.Notify(context.Background(), alert), "webhook returned 500 Internal Server Error")
+}
+
+func TestNotifyCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands are for sh")
+	}
+	assert := assert.New(t)
+
+	alert := Alert{
+		Rule:    Rule{Name: "cases", Metric: "new_cases", Above: float(12), Command: "echo $CLATEST_RULE $CLATEST_COUNTRY $CLATEST_VALUE"},
+		Country: "Australia",
+		Date:    time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC),
+		Value:   13,
+	}
+	stdout := new(bytes.Buffer)
+	n := &Notifier{Client: http.DefaultClient, Stdout: stdout, Stderr: ioutil.Discard}
+	assert.NoError(n.Notify(context.Background(), alert))
+	assert.Equal("cases Australia 13\n", stdout.String())
+
+	alert.Rule.Command = "exit 3"
+	assert.EqualError(n.Notify(context.Background(), alert), "command failed: exit status 3")
+}
@@ -0,0 +1,211 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnDorian/clatest/client"
+	"gopkg.in/yaml.v2"
+)
+
+//week the days a rise is measured over
+const week = 7
+
+//metric a value of the days of a series. Metrics over more than one day are the average daily change of
+//the cumulative value, so new_cases is the change over one day
+type metric struct {
+	value func(client.Day) int
+	days  int
+}
+
+var metrics = map[string]metric{
+	"cases":             {value: func(d client.Day) int { return d.Cases }},
+	"deaths":            {value: func(d client.Day) int { return d.Deaths }},
+	"new_cases":         {value: func(d client.Day) int { return d.Cases }, days: 1},
+	"new_deaths":        {value: func(d client.Day) int { return d.Deaths }, days: 1},
+	"new_cases_7d_avg":  {value: func(d client.Day) int { return d.Cases }, days: week},
+	"new_deaths_7d_avg": {value: func(d client.Day) int { return d.Deaths }, days: week},
+}
+
+//at the metric offset days before the last day of the series. The days are found by date, so a day missing
+//from the series is an error rather than quietly stretching the average over more days
+func (m metric) at(ts client.TimeSeries, offset int) (float64, error) {
+	byDate := map[int64]client.Day{}
+	for _, obs := range ts.Data {
+		byDate[obs.Date.Unix()] = obs
+	}
+	find := func(date time.Time) (client.Day, error) {
+		obs, ok := byDate[date.Unix()]
+		if !ok {
+			return obs, fmt.Errorf("no data on %v", date.Format("2006-01-02"))
+		}
+		return obs, nil
+	}
+	end := ts.Data[len(ts.Data)-1].Date.AddDate(0, 0, -offset)
+	last, err := find(end)
+	if err != nil {
+		return 0, err
+	}
+	if m.days == 0 {
+		return float64(m.value(last)), nil
+	}
+	first, err := find(end.AddDate(0, 0, -m.days))
+	if err != nil {
+		return 0, err
+	}
+	return float64(m.value(last)-m.value(first)) / float64(m.days), nil
+}
+
+//Rule a threshold on a metric of a country, which either has to be above a value or rise by a percentage on
+//the week before. When it fires the alert is posted to the webhook and/or passed to the command
+type Rule struct {
+	Name    string   `yaml:"name"`
+	Country string   `yaml:"country"`
+	Metric  string   `yaml:"metric"`
+	Above   *float64 `yaml:"above,omitempty"`
+	Rise    *float64 `yaml:"rise,omitempty"` // Percent
+	Webhook string   `yaml:"webhook,omitempty"`
+	Command string   `yaml:"command,omitempty"`
+}
+
+//rulesFile the layout of the rules file
+type rulesFile struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+//LoadRules reads and checks the rules in the yaml file at path
+func LoadRules(path string) ([]Rule, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRules(b)
+}
+
+//ParseRules parses and checks the rules in a yaml document. Every rule needs a unique name, as that is
+//how the state remembers it has fired
+func ParseRules(b []byte) ([]Rule, error) {
+	var file rulesFile
+	err := yaml.UnmarshalStrict(b, &file)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for i, rule := range file.Rules {
+		err = rule.validate()
+		if err != nil {
+			return nil, fmt.Errorf("rule %v: %v", i+1, err)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("rule %v: the name %q is used by another rule", i+1, rule.Name)
+		}
+		names[rule.Name] = true
+	}
+	return file.Rules, nil
+}
+
+func (r Rule) validate() error {
+	switch {
+	case r.Name == "":
+		return fmt.Errorf("missing name")
+	case r.Country == "":
+		return fmt.Errorf("missing country")
+	case r.Above == nil && r.Rise == nil:
+		return fmt.Errorf("needs either above or rise")
+	case r.Above != nil && r.Rise != nil:
+		return fmt.Errorf("can only have one of above or rise")
+	case r.Webhook == "" && r.Command == "":
+		return fmt.Errorf("needs a webhook or a command")
+	}
+	if _, ok := metrics[r.Metric]; !ok {
+		var names []string
+		for name := range metrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown metric %q, use one of %v", r.Metric, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+//Days the days of data the rule needs up to the latest day
+func (r Rule) Days() int {
+	days := metrics[r.Metric].days + 1
+	if r.Rise != nil {
+		days += week
+	}
+	return days
+}
+
+//Alert the value of a rule's metric on the latest day of its country
+type Alert struct {
+	Rule     Rule
+	Country  string
+	Date     time.Time
+	Value    float64
+	Previous float64 // The value the week before, only for rules on a rise
+}
+
+func (a Alert) String() string {
+	date := a.Date.Format("2006-01-02")
+	if a.Rule.Rise != nil {
+		return fmt.Sprintf("%v: %v of %v rose %v%% on the week before to %v on %v",
+			a.Rule.Name, a.Rule.Metric, a.Country, formatFloat(a.rise()), formatFloat(a.Value), date)
+	}
+	return fmt.Sprintf("%v: %v of %v is %v (above %v) on %v",
+		a.Rule.Name, a.Rule.Metric, a.Country, formatFloat(a.Value), formatFloat(*a.Rule.Above), date)
+}
+
+//rise the percentage the value rose on the week before, rounded to a tenth
+func (a Alert) rise() float64 {
+	return float64(int64((a.Value-a.Previous)/a.Previous*1000+0.5)) / 10
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+//Evaluate the rule on the latest day of the series, and whether it fires. A rise from nothing doesn't fire,
+//as any rise would be infinite
+func (r Rule) Evaluate(ts client.TimeSeries) (Alert, bool, error) {
+	m := metrics[r.Metric]
+	alert := Alert{Rule: r, Country: r.Country}
+	if len(ts.Data) < r.Days() {
+		return alert, false, fmt.Errorf("needs %v days of data, only got %v", r.Days(), len(ts.Data))
+	}
+	value, err := m.at(ts, 0)
+	if err != nil {
+		return alert, false, err
+	}
+	last := ts.Data[len(ts.Data)-1]
+	alert.Country, alert.Date, alert.Value = last.Country, last.Date, value
+	if r.Above != nil {
+		return alert, value > *r.Above, nil
+	}
+	previous, err := m.at(ts, week)
+	if err != nil {
+		return alert, false, err
+	}
+	alert.Previous = previous
+	return alert, previous > 0 && (value-previous)/previous*100 >= *r.Rise, nil
+}
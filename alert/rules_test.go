@@ -0,0 +1,181 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johnDorian/clatest/client"
+	"github.com/stretchr/testify/assert"
+)
+
+//days a series of Australia up to 2021-03-25 with one day per cumulative value
+func days(cases ...int) client.TimeSeries {
+	var ts client.TimeSeries
+	last := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+	for i, c := range cases {
+		ts.Data = append(ts.Data, client.Day{Country: "Australia", Date: last.AddDate(0, 0, i-len(cases)+1), Cases: c, Deaths: c / 10})
+	}
+	return ts
+}
+
+//skip the series without its i'th day
+func skip(ts client.TimeSeries, i int) client.TimeSeries {
+	ts.Data = append(ts.Data[:i:i], ts.Data[i+1:]...)
+	return ts
+}
+
+func float(f float64) *float64 {
+	return &f
+}
+
+func TestParseRules(t *testing.T) {
+	assert := assert.New(t)
+
+	rules, err := ParseRules([]byte(`
+rules:
+  - name: australia-rising
+    country: Australia
+    metric: new_cases_7d_avg
+    rise: 20
+    webhook: http://localhost/hook
+  - name: daily-deaths
+    country: australia
+    metric: new_deaths
+    above: 50
+    command: echo $CLATEST_MESSAGE
+`))
+	assert.NoError(err)
+	assert.Equal([]Rule{
+		{Name: "australia-rising", Country: "Australia", Metric: "new_cases_7d_avg", Rise: float(20), Webhook: "http://localhost/hook"},
+		{Name: "daily-deaths", Country: "australia", Metric: "new_deaths", Above: float(50), Command: "echo $CLATEST_MESSAGE"},
+	}, rules)
+	assert.Equal(15, rules[0].Days())
+	assert.Equal(2, rules[1].Days())
+
+	tests := []struct {
+		yaml     string
+		expected string
+	}{
+		{"rules:\n  - country: Australia\n    metric: cases\n    above: 1\n    command: x\n", "rule 1: missing name"},
+		{"rules:\n  - name: a\n    metric: cases\n    above: 1\n    command: x\n", "rule 1: missing country"},
+		{"rules:\n  - name: a\n    country: Australia\n    metric: cases\n    command: x\n", "rule 1: needs either above or rise"},
+		{"rules:\n  - name: a\n    country: Australia\n    metric: cases\n    above: 1\n    rise: 1\n    command: x\n", "rule 1: can only have one of above or rise"},
+		{"rules:\n  - name: a\n    country: Australia\n    metric: cases\n    above: 1\n", "rule 1: needs a webhook or a command"},
+		{"rules:\n  - name: a\n    country: Australia\n    metric: tests\n    above: 1\n    command: x\n", "rule 1: unknown metric \"tests\", use one of cases, deaths, new_cases, new_cases_7d_avg, new_deaths, new_deaths_7d_avg"},
+		{"rules:\n  - name: a\n    country: Australia\n    metric: cases\n    above: 1\n    command: x\n  - name: a\n    country: Australia\n    metric: cases\n    above: 2\n    command: x\n", "rule 2: the name \"a\" is used by another rule"},
+	}
+	for _, test := range tests {
+		_, err := ParseRules([]byte(test.yaml))
+		assert.EqualError(err, test.expected)
+	}
+
+	_, err = ParseRules([]byte("rules:\n  - name: a\n    treshold: 1\n"))
+	assert.Error(err)
+	_, err = LoadRules("testdata/missing.yaml")
+	assert.Error(err)
+}
+
+func TestEvaluate(t *testing.T) {
+	assert := assert.New(t)
+
+	// 10 new cases a day for a week, then 13 a day
+	rising := days(0, 10, 20, 30, 40, 50, 60, 70, 83, 96, 109, 122, 135, 148, 161)
+
+	tests := []struct {
+		rule     Rule
+		ts       client.TimeSeries
+		fires    bool
+		value    float64
+		previous float64
+		message  string
+		err      string
+	}{
+		{
+			rule:     Rule{Name: "rising", Metric: "new_cases_7d_avg", Rise: float(20)},
+			ts:       rising,
+			fires:    true,
+			value:    13,
+			previous: 10,
+			message:  "rising: new_cases_7d_avg of Australia rose 30% on the week before to 13 on 2021-03-25",
+		},
+		{
+			rule:     Rule{Name: "rising", Metric: "new_cases_7d_avg", Rise: float(30.5)},
+			ts:       rising,
+			value:    13,
+			previous: 10,
+		},
+		{
+			rule:    Rule{Name: "cases", Metric: "new_cases", Above: float(12)},
+			ts:      rising,
+			fires:   true,
+			value:   13,
+			message: "cases: new_cases of Australia is 13 (above 12) on 2021-03-25",
+		},
+		{
+			rule:  Rule{Name: "deaths", Metric: "deaths", Above: float(16)},
+			ts:    rising,
+			value: 16,
+		},
+		{
+			// a rise from nothing
+			rule:  Rule{Name: "rising", Metric: "new_cases", Rise: float(20)},
+			ts:    days(0, 0, 0, 0, 0, 0, 0, 0, 5),
+			value: 5,
+		},
+		{
+			rule: Rule{Name: "rising", Metric: "new_cases_7d_avg", Rise: float(20)},
+			ts:   days(0, 10, 20, 30, 40, 50, 60, 70, 80),
+			err:  "needs 15 days of data, only got 9",
+		},
+		{
+			rule: Rule{Name: "cases", Metric: "new_cases", Above: float(1)},
+			ts:   days(10),
+			err:  "needs 2 days of data, only got 1",
+		},
+		{
+			// 100 new cases a day, with a day skipped, can't be averaged by counting days
+			rule: Rule{Name: "cases", Metric: "new_cases_7d_avg", Above: float(100)},
+			ts:   skip(days(0, 100, 200, 300, 400, 500, 600, 700, 800, 900), 2),
+			err:  "no data on 2021-03-18",
+		},
+		{
+			// the week before needs the skipped day
+			rule: Rule{Name: "rising", Metric: "new_cases", Rise: float(20)},
+			ts:   skip(days(0, 100, 200, 300, 400, 500, 600, 700, 800, 900), 1),
+			err:  "no data on 2021-03-17",
+		},
+	}
+
+	for _, test := range tests {
+		test.rule.Country = "australia"
+		alert, fires, err := test.rule.Evaluate(test.ts)
+		if test.err != "" {
+			assert.EqualError(err, test.err)
+			continue
+		}
+		assert.NoError(err)
+		assert.Equal(test.fires, fires, test.rule.Name)
+		assert.Equal(test.value, alert.Value, test.rule.Name)
+		assert.Equal(test.previous, alert.Previous, test.rule.Name)
+		assert.Equal("Australia", alert.Country)
+		if test.message != "" {
+			assert.Equal(test.message, alert.String())
+		}
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestState(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "clatest-alert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "nested", "alerts.json")
+
+	state, err := LoadState(path)
+	assert.NoError(err)
+	assert.Empty(state.Fired)
+
+	fired := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+	state.Fired["australia-rising"] = fired
+	assert.NoError(state.Save(path))
+
+	state, err = LoadState(path)
+	assert.NoError(err)
+	assert.Equal(map[string]time.Time{"australia-rising": fired}, state.Fired)
+	files, _ := ioutil.ReadDir(filepath.Dir(path))
+	assert.Len(files, 1)
+
+	ioutil.WriteFile(path, []byte("{}"), 0644)
+	state, err = LoadState(path)
+	assert.NoError(err)
+	assert.NotNil(state.Fired)
+
+	ioutil.WriteFile(path, []byte("not json"), 0644)
+	_, err = LoadState(path)
+	assert.Error(err)
+}
@@ -0,0 +1,326 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//DefaultCacheTTL how long a response is used before asking the server again. The data only changes once a day
+const DefaultCacheTTL = time.Hour
+
+//Cache an http.RoundTripper which keeps successful responses on disk. A response younger than TTL is
+//returned without a request, an older one is revalidated with its ETag or Last-Modified date
+type Cache struct {
+	Dir       string
+	TTL       time.Duration
+	Transport http.RoundTripper
+}
+
+//cacheEntry the metadata stored next to each cached body
+type cacheEntry struct {
+	URL          string      `json:"url"`
+	Header       http.Header `json:"header"`
+	ETag         string      `json:"etag"`
+	LastModified string      `json:"last_modified"`
+	Fetched      time.Time   `json:"fetched"`
+}
+
+//CacheInfo a summary of what is in the cache
+type CacheInfo struct {
+	Dir     string
+	Entries int
+	Size    int64
+	Oldest  time.Time
+	Newest  time.Time
+}
+
+//NewCache returns a cache in dir which sends any request it can't answer through transport
+func NewCache(dir string, ttl time.Duration, transport http.RoundTripper) *Cache {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Cache{Dir: dir, TTL: ttl, Transport: transport}
+}
+
+//DefaultCacheDir the clatest directory in the user's cache dir
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "clatest"), nil
+}
+
+//Wrap sends the requests of httpClient through the cache
+func (c *Cache) Wrap(httpClient *http.Client) {
+	c.Transport = httpClient.Transport
+	if c.Transport == nil {
+		c.Transport = http.DefaultTransport
+	}
+	httpClient.Transport = c
+}
+
+//RoundTrip answers GET requests from the cache when possible, and stores any successful response
+func (c *Cache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return c.Transport.RoundTrip(req)
+	}
+	key := cacheKey(req.URL.String())
+	entry, cached := c.load(key)
+	if cached && time.Since(entry.Fetched) < c.TTL {
+		if resp, err := c.cachedResponse(req, key, entry); err == nil {
+			return resp, nil
+		}
+		cached = false
+	}
+
+	if cached && (entry.ETag != "" || entry.LastModified != "") {
+		req = req.Clone(req.Context())
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+	resp, err := c.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached:
+		resp.Body.Close()
+		entry.Fetched = time.Now()
+		c.storeEntry(key, entry)
+		return c.cachedResponse(req, key, entry)
+	case resp.StatusCode == http.StatusOK:
+		entry = cacheEntry{
+			URL:          req.URL.String(),
+			Header:       resp.Header,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			Fetched:      time.Now(),
+		}
+		return c.storeResponse(key, entry, resp)
+	default:
+		return resp, nil
+	}
+}
+
+//storeResponse streams the body of resp into the cache and returns the response read back from the cached
+//file, so a large body is never held in memory. A cache which can't be written is only slower, so the
+//response is still returned
+func (c *Cache) storeResponse(key string, entry cacheEntry, resp *http.Response) (*http.Response, error) {
+	tmp, err := c.tempFile(key + ".body")
+	if err != nil {
+		return resp, nil
+	}
+	_, err = io.Copy(tmp, resp.Body)
+	resp.Body.Close()
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	resp.Body = tmp
+	if os.Rename(tmp.Name(), filepath.Join(c.Dir, key+".body")) == nil {
+		c.storeEntry(key, entry)
+	} else {
+		resp.Body = removeOnClose{tmp}
+	}
+	return resp, nil
+}
+
+//Clear removes every cached response
+func (c *Cache) Clear() error {
+	files, err := c.files()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		err = os.Remove(file)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Info counts the cached responses and their size on disk
+func (c *Cache) Info() (CacheInfo, error) {
+	info := CacheInfo{Dir: c.Dir}
+	files, err := c.files()
+	if err != nil {
+		return info, err
+	}
+	for _, file := range files {
+		stat, err := os.Stat(file)
+		if err != nil {
+			return info, err
+		}
+		info.Size += stat.Size()
+		if !strings.HasSuffix(file, ".json") {
+			continue
+		}
+		var entry cacheEntry
+		b, err := ioutil.ReadFile(file)
+		if err != nil || json.Unmarshal(b, &entry) != nil {
+			continue
+		}
+		info.Entries++
+		if info.Oldest.IsZero() || entry.Fetched.Before(info.Oldest) {
+			info.Oldest = entry.Fetched
+		}
+		if entry.Fetched.After(info.Newest) {
+			info.Newest = entry.Fetched
+		}
+	}
+	return info, nil
+}
+
+//Print print the cache summary to an os.File
+func (i CacheInfo) Print(output io.Writer, format string) {
+	timestamp := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Local().Format("2006-01-02 15:04:05")
+	}
+	writeTable([][]string{{
+		i.Dir,
+		fmt.Sprint(i.Entries),
+		fmt.Sprint(i.Size),
+		timestamp(i.Oldest),
+		timestamp(i.Newest),
+	}}, []string{"Directory", "Entries", "Bytes", "Oldest", "Newest"}, output, format)
+}
+
+//files the body and metadata files of the cache, a missing directory is an empty cache
+func (c *Cache) files() ([]string, error) {
+	var files []string
+	for _, pattern := range []string{"*.json", "*.body"} {
+		matches, err := filepath.Glob(filepath.Join(c.Dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+//load reads the metadata stored under key, which is only cached when its body is too
+func (c *Cache) load(key string) (cacheEntry, bool) {
+	var entry cacheEntry
+	b, err := ioutil.ReadFile(filepath.Join(c.Dir, key+".json"))
+	if err != nil || json.Unmarshal(b, &entry) != nil {
+		return entry, false
+	}
+	if _, err = os.Stat(filepath.Join(c.Dir, key+".body")); err != nil {
+		return entry, false
+	}
+	return entry, true
+}
+
+//storeEntry writes the metadata stored under key
+func (c *Cache) storeEntry(key string, entry cacheEntry) {
+	b, err := json.Marshal(entry)
+	if err == nil {
+		c.writeFile(key+".json", b)
+	}
+}
+
+//writeFile writes name in the cache directory through a temporary file, so a reader never sees half of it
+func (c *Cache) writeFile(name string, b []byte) error {
+	tmp, err := c.tempFile(name)
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(b)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(c.Dir, name))
+}
+
+//tempFile creates a temporary file in the cache directory to be renamed to name once it is written
+func (c *Cache) tempFile(name string) (*os.File, error) {
+	err := os.MkdirAll(c.Dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.TempFile(c.Dir, name+".*.tmp")
+}
+
+//cachedResponse the body cached under key as a response to req, read from the file as it is used
+func (c *Cache) cachedResponse(req *http.Request, key string, entry cacheEntry) (*http.Response, error) {
+	body, err := os.Open(filepath.Join(c.Dir, key+".body"))
+	if err != nil {
+		return nil, err
+	}
+	stat, err := body.Stat()
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.Header,
+		Body:          body,
+		ContentLength: stat.Size(),
+		Request:       req,
+	}, nil
+}
+
+//removeOnClose a response body read from a temporary file, which is removed once the body is closed
+type removeOnClose struct {
+	*os.File
+}
+
+func (f removeOnClose) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+func cacheKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,154 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "clatest-cache")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	requests, revalidated := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/historical/australia" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Country not found or doesn't have any historical data"}`))
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			revalidated++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(responseData))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL + "/historical/%v?lastdays=%v")
+	cache := NewCache(dir, time.Hour, nil)
+	cache.Wrap(c.Client)
+	day := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		data, err := c.Get("australia", day, day, false)
+		assert.NoError(err)
+		assert.Equal(29239, data.TimeSeries.Data[0].Cases)
+	}
+	assert.Equal(1, requests)
+
+	cache.TTL = 0
+	data, err := c.Get("australia", day, day, false)
+	assert.NoError(err)
+	assert.Equal(29239, data.TimeSeries.Data[0].Cases)
+	assert.Equal(2, requests)
+	assert.Equal(1, revalidated)
+
+	// errors aren't cached
+	for i := 0; i < 2; i++ {
+		_, err = c.Get("azzz", day, day, false)
+		assert.EqualError(err, "Country not found or doesn't have any historical data")
+	}
+	assert.Equal(4, requests)
+
+	info, err := cache.Info()
+	assert.NoError(err)
+	assert.Equal(dir, info.Dir)
+	assert.Equal(1, info.Entries)
+	assert.True(info.Size > int64(len(responseData)))
+	assert.False(info.Newest.IsZero())
+
+	assert.NoError(cache.Clear())
+	info, err = cache.Info()
+	assert.NoError(err)
+	assert.Equal(CacheInfo{Dir: dir}, info)
+
+	_, err = c.Get("australia", day, day, false)
+	assert.NoError(err)
+	assert.Equal(5, requests)
+}
+
+func TestCacheStreamsBody(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "clatest-cache")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	body := bytes.Repeat([]byte("iso_code,location,date\n"), 100000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{}
+	NewCache(dir, time.Hour, nil).Wrap(httpClient)
+	for i := 0; i < 2; i++ {
+		resp, err := httpClient.Get(server.URL)
+		assert.NoError(err)
+		b, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(err)
+		assert.NoError(resp.Body.Close())
+		assert.Equal(body, b)
+	}
+	cached, err := ioutil.ReadFile(filepath.Join(dir, cacheKey(server.URL)+".body"))
+	if assert.NoError(err) {
+		assert.Equal(body, cached)
+	}
+
+	// a cache directory which can't be created still returns the response
+	file := filepath.Join(dir, "file")
+	assert.NoError(ioutil.WriteFile(file, nil, 0644))
+	httpClient = &http.Client{}
+	NewCache(filepath.Join(file, "cache"), time.Hour, nil).Wrap(httpClient)
+	resp, err := httpClient.Get(server.URL)
+	assert.NoError(err)
+	b, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(body, b)
+}
+
+func TestCacheMissingDir(t *testing.T) {
+	assert := assert.New(t)
+	cache := NewCache("testdata/missing", time.Hour, nil)
+	info, err := cache.Info()
+	assert.NoError(err)
+	assert.Equal(0, info.Entries)
+	assert.NoError(cache.Clear())
+}
+
+func TestPrintCacheInfo(t *testing.T) {
+	assert := assert.New(t)
+	buf := new(bytes.Buffer)
+	CacheInfo{Dir: "/tmp/clatest", Entries: 2, Size: 1024}.Print(buf, "csv")
+	assert.Equal("Directory,Entries,Bytes,Oldest,Newest\n/tmp/clatest,2,1024,,\n", buf.String())
+}
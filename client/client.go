@@ -21,10 +21,13 @@ the latest covid numbers and report these to the cmd line via stdout or save the
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -33,8 +36,16 @@ import (
 type APIClient struct {
 	Client     *http.Client
 	RequestURL string
+	BaseURL    string // Used for the endpoints other than the historical data
+	Retry      *Retry // Tries failed requests again, set Retry.Retries to 0 to turn it off
 }
 
+//DefaultBaseURL the disease.sh covid-19 api
+const DefaultBaseURL = "https://disease.sh/v3/covid-19"
+
+//DefaultTimeout the longest a request can take, including any retries
+const DefaultTimeout = time.Minute
+
 //RawData the raw response timeseries
 type RawData struct {
 	Cases     map[string]int `json:"cases"`
@@ -44,60 +55,291 @@ type RawData struct {
 
 //APIResponse the main response from the server
 type APIResponse struct {
-	Country    string   `json:"country"`
-	Province   []string `json:"province"`
-	RawData    RawData  `json:"timeline"`
+	Country    string    `json:"country"`
+	Province   Provinces `json:"province"`
+	RawData    RawData   `json:"timeline"`
 	TimeSeries TimeSeries
 }
 
 var (
-	ErrorBadDateFormat = errors.New("Incorrect Date Format") //Bad date format from the command line
+	ErrorBadDateFormat = errors.New("Incorrect Date Format") //Bad date format from the command line or the server
 )
 
+//GlobalNames the reserved country names which return the totals for the whole world
+var GlobalNames = []string{"all", "global"}
+
+//IsGlobal whether country is one of the reserved names for the whole world
+func IsGlobal(country string) bool {
+	for _, name := range GlobalNames {
+		if strings.EqualFold(strings.TrimSpace(country), name) {
+			return true
+		}
+	}
+	return false
+}
+
+//UnmarshalJSON decodes a country response, or the bare timeline the server returns for the whole world
+func (r *APIResponse) UnmarshalJSON(b []byte) error {
+	type response APIResponse
+	var res response
+	err := json.Unmarshal(b, &res)
+	if err != nil {
+		return err
+	}
+	if res.RawData.Cases == nil {
+		err = json.Unmarshal(b, &res.RawData)
+		if err != nil {
+			return err
+		}
+	}
+	*r = APIResponse(res)
+	return nil
+}
+
 //NewClient returns a client for the user to query the api server
 func NewClient(RequestURL string) *APIClient {
+	retry := NewRetry(&http.Transport{
+		IdleConnTimeout: 10 * time.Second,
+	}, DefaultRetries)
 	httpClient := &http.Client{
-		Transport: &http.Transport{
-			IdleConnTimeout: 10 * time.Second,
-		},
+		Transport: retry,
+		Timeout:   DefaultTimeout,
+	}
+	baseURL := DefaultBaseURL
+	if i := strings.Index(RequestURL, "/historical/"); i >= 0 {
+		baseURL = RequestURL[:i]
+	}
+	return &APIClient{Client: httpClient, RequestURL: RequestURL, BaseURL: baseURL, Retry: retry}
+}
+
+//getJSON requests url and decodes the json response into v
+func (c *APIClient) getJSON(url string, v interface{}) error {
+	return c.getJSONContext(context.Background(), url, v)
+}
+
+//getJSONContext requests url, which is cancelled along with ctx, and decodes the json response into v
+func (c *APIClient) getJSONContext(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
 	}
-	return &APIClient{Client: httpClient, RequestURL: RequestURL}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return parseErrorMessage(resp)
+	}
+
+	d := json.NewDecoder(resp.Body)
+	return d.Decode(v)
 }
 
 //Get the main get function which queries the server
 func (c *APIClient) Get(country string, from, to time.Time, latest bool) (APIResponse, error) {
+	return c.GetContext(context.Background(), country, from, to, latest)
+}
+
+//GetContext queries the server like Get, giving up when ctx is cancelled
+func (c *APIClient) GetContext(ctx context.Context, country string, from, to time.Time, latest bool) (APIResponse, error) {
 	var data APIResponse
 	totalDays := calcDays(from)
+	global := IsGlobal(country)
+	if global {
+		country = "all"
+	}
 
-	resp, err := c.Client.Get(fmt.Sprintf(c.RequestURL, country, totalDays))
-
+	err := c.getJSONContext(ctx, fmt.Sprintf(c.RequestURL, url.PathEscape(country), totalDays), &data)
 	if err != nil {
 		return data, err
 	}
+	if global {
+		data.Country = "Global"
+	}
+	err = data.FormatResponse(from, to, latest)
+	return data, err
 
-	if resp.StatusCode != 200 {
-		return data, parseErrorMessage(resp)
+}
+
+//GetGlobal queries the server for the totals of the whole world
+func (c *APIClient) GetGlobal(from, to time.Time, latest bool) (APIResponse, error) {
+	return c.GetGlobalContext(context.Background(), from, to, latest)
+}
+
+//GetGlobalContext queries the server like GetGlobal, giving up when ctx is cancelled
+func (c *APIClient) GetGlobalContext(ctx context.Context, from, to time.Time, latest bool) (APIResponse, error) {
+	return c.GetContext(ctx, "all", from, to, latest)
+}
+
+//GetBatch queries the server for several countries with a single request. The responses are in the
+//same order as countries, any country rejected by the server is left out and reported in a SeriesError.
+//A country with some keys which aren't dates keeps the rest of its days and is reported too (see SplitBadDates)
+func (c *APIClient) GetBatch(countries []string, from, to time.Time, latest bool) ([]APIResponse, error) {
+	return c.GetBatchContext(context.Background(), countries, from, to, latest)
+}
+
+//GetBatchContext queries the server like GetBatch, giving up when ctx is cancelled
+func (c *APIClient) GetBatchContext(ctx context.Context, countries []string, from, to time.Time, latest bool) ([]APIResponse, error) {
+	return c.getBatch(ctx, escapeNames(countries), countries, from, to, latest, func(r APIResponse) string {
+		return r.Country
+	})
+}
+
+//GetProvinces queries the server for one or more provinces of a country with a single request. The responses
+//are in the same order as provinces, any province rejected by the server is left out and reported in a SeriesError
+func (c *APIClient) GetProvinces(country string, provinces []string, from, to time.Time, latest bool) ([]APIResponse, error) {
+	return c.GetProvincesContext(context.Background(), country, provinces, from, to, latest)
+}
+
+//GetProvincesContext queries the server like GetProvinces, giving up when ctx is cancelled
+func (c *APIClient) GetProvincesContext(ctx context.Context, country string, provinces []string, from, to time.Time, latest bool) ([]APIResponse, error) {
+	data, err := c.getBatch(ctx, url.PathEscape(country)+"/"+escapeNames(provinces), provinces, from, to, latest, func(r APIResponse) string {
+		if len(r.Province) == 0 {
+			return ""
+		}
+		return r.Province[0]
+	})
+	for i := range data {
+		if len(data[i].Province) == 0 {
+			continue
+		}
+		for j := range data[i].TimeSeries.Data {
+			data[i].TimeSeries.Data[j].Province = data[i].Province[0]
+		}
 	}
+	return data, err
+}
 
-	d := json.NewDecoder(resp.Body)
-	err = d.Decode(&data)
+//getBatch requests the comma separated names in path, which the server answers with an array of responses (or a
+//single response for a single name). name returns the key a response is matched against when one is missing
+func (c *APIClient) getBatch(ctx context.Context, path string, names []string, from, to time.Time, latest bool, name func(APIResponse) string) ([]APIResponse, error) {
+	var data []APIResponse
+	totalDays := calcDays(from)
 
+	var raw json.RawMessage
+	err := c.getJSONContext(ctx, fmt.Sprintf(c.RequestURL, path, totalDays), &raw)
+	if err != nil {
+		return data, err
+	}
+	// a single name is returned as an object rather than an array
+	var items []batchItem
+	if trimmed := strings.TrimSpace(string(raw)); strings.HasPrefix(trimmed, "{") {
+		var item batchItem
+		err = json.Unmarshal(raw, &item)
+		items = append(items, item)
+	} else {
+		err = json.Unmarshal(raw, &items)
+	}
 	if err != nil {
 		return data, err
 	}
-	data.FormatResponse(from, to, latest)
 
+	failed := SeriesError{}
+	for i, key := range names {
+		item, ok := matchBatchItem(items, names, i, name)
+		switch {
+		case !ok:
+			failed[key] = &notFoundError{"Country not found or doesn't have any historical data"}
+		case item.Message != "":
+			failed[key] = &notFoundError{item.Message}
+		default:
+			err = item.FormatResponse(from, to, latest)
+			if err != nil {
+				failed[key] = err
+				if !partialSeries(err) {
+					continue
+				}
+			}
+			data = append(data, item.APIResponse)
+		}
+	}
+	if len(failed) > 0 {
+		return data, failed
+	}
 	return data, nil
+}
+
+//escapeNames the names as a single path segment, with the names separated by commas
+func escapeNames(names []string) string {
+	escaped := make([]string, len(names))
+	for i, name := range names {
+		escaped[i] = url.PathEscape(strings.TrimSpace(name))
+	}
+	return strings.Join(escaped, ",")
+}
+
+//batchItem a single element of a batched response, which is either a country or an error message
+type batchItem struct {
+	APIResponse
+	Message string `json:"message"`
+}
+
+func (b *batchItem) UnmarshalJSON(data []byte) error {
+	var errMessage struct {
+		Message string `json:"message"`
+	}
+	err := json.Unmarshal(data, &errMessage)
+	if err != nil {
+		return err
+	}
+	b.Message = errMessage.Message
+	return json.Unmarshal(data, &b.APIResponse)
+}
 
+//matchBatchItem finds the response for names[i]. The server keeps the requested order, so when every
+//name has a response they are matched by position, otherwise by name
+func matchBatchItem(items []batchItem, names []string, i int, name func(APIResponse) string) (batchItem, bool) {
+	if len(items) == len(names) {
+		return items[i], true
+	}
+	for _, item := range items {
+		if strings.EqualFold(name(item.APIResponse), names[i]) {
+			return item, true
+		}
+	}
+	return batchItem{}, false
 }
 
-//FormatResponse format the timeseries map to something with more structure (i.e. []Day)
+//Provinces the provinces of a country, which the server returns as a single string when a province is queried
+type Provinces []string
+
+//UnmarshalJSON decodes either a list of provinces or a single province
+func (p *Provinces) UnmarshalJSON(b []byte) error {
+	var province string
+	if err := json.Unmarshal(b, &province); err == nil {
+		*p = nil
+		if province != "" {
+			*p = Provinces{province}
+		}
+		return nil
+	}
+	var provinces []string
+	err := json.Unmarshal(b, &provinces)
+	*p = provinces
+	return err
+}
+
+//Print print the provinces to an os.File
+func (p Provinces) Print(output io.Writer, format string) {
+	var strData [][]string
+	for _, province := range p {
+		strData = append(strData, []string{province})
+	}
+	writeTable(strData, []string{"Province"}, output, format)
+}
+
+//FormatResponse format the timeseries map to something with more structure (i.e. []Day). Every key which
+//is a date is kept, any other key is left out and named in a BadDatesError
 func (r *APIResponse) FormatResponse(from, to time.Time, latest bool) error {
 	var timeSeries TimeSeries
+	var badDates []string
 	for date := range r.RawData.Cases {
-		formattedTime, err := cleanReturnedDate(date)
+		formattedTime, err := parseDate(date)
 		if err != nil {
-			return err
+			badDates = append(badDates, date)
+			continue
 		}
 		timeSeries.Data = append(timeSeries.Data, Day{
 			Country:   r.Country,
@@ -108,37 +350,14 @@ func (r *APIResponse) FormatResponse(from, to time.Time, latest bool) error {
 		})
 	}
 
+	days := len(timeSeries.Data)
 	r.TimeSeries = timeSeries
 	r.TimeSeries.Order()
 	r.TimeSeries.Filter(from, to, latest)
-	return nil
-
-}
-
-func cleanReturnedDate(date string) (time.Time, error) {
-	dateParts := strings.Split(date, "/")
-	if len(dateParts) != 3 {
-		return time.Time{}, ErrorBadDateFormat
-	}
-	cleanDate := fmt.Sprintf("20%v-%v%v-%v%v", dateParts[2], strings.Repeat("0", 2-len(dateParts[0])), dateParts[0], strings.Repeat("0", 2-len(dateParts[1])), dateParts[1])
-	formattedTime, err := time.Parse("2006-01-02", cleanDate)
-
-	return formattedTime, err
+	return badDatesError(badDates, days)
 
 }
 
 func calcDays(from time.Time) int {
 	return int(time.Now().Sub(from).Hours()/24) + 1
 }
-
-func parseErrorMessage(resp *http.Response) error {
-	var errMessage struct {
-		Message string `json:"message"`
-	}
-	errdecoder := json.NewDecoder(resp.Body)
-	err := errdecoder.Decode(&errMessage)
-	if err != nil {
-		return err
-	}
-	return errors.New(errMessage.Message)
-}
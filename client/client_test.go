@@ -16,8 +16,10 @@ limitations under the License.
 package client
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
-	"io/ioutil"
+	"errors"
 	"log"
 	"net/http"
 	"net/http/httptest"
@@ -151,6 +153,7 @@ func TestGet(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(server.URL + "/%v%v")
+	client.Retry.Base = time.Millisecond
 
 	for _, test := range tests {
 		apiResponse, err := client.Get(test.country, test.from, test.to, test.latest)
@@ -168,27 +171,26 @@ func TestGet(t *testing.T) {
 	}
 
 }
-func TestCleanReturnedDate(t *testing.T) {
+func TestGetContext(t *testing.T) {
 	assert := assert.New(t)
-	tests := []struct {
-		in          string
-		expected    time.Time
-		expectedErr error
-	}{
-		{in: "21-1-1", expected: time.Time{}, expectedErr: ErrorBadDateFormat},
-		{in: "1/1/21", expected: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), expectedErr: nil},
-	}
-
-	for _, test := range tests {
-		formatted, err := cleanReturnedDate(test.in)
-		if test.expectedErr != nil {
-			assert.Error(err)
-		} else {
-			assert.NoError(err)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
 		}
-		assert.Equal(test.expected, formatted)
-	}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL + "/%v%v")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := client.GetContext(ctx, "australia", time.Now(), time.Now(), true)
+	assert.True(errors.Is(err, context.DeadlineExceeded))
 
+	client.Client.Timeout = 10 * time.Millisecond
+	client.Retry.Retries = 0
+	_, err = client.Get("australia", time.Now(), time.Now(), true)
+	assert.Error(err)
 }
 
 func TestCalcDays(t *testing.T) {
@@ -221,27 +223,204 @@ func TestNewClient(t *testing.T) {
 
 }
 
-func TestParseErrorMessage(t *testing.T) {
+func TestGetBatch(t *testing.T) {
 	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch strings.TrimRight(r.URL.Path, "0123456789") {
+		case "/australia,new zealand":
+			w.Write([]byte("[" + responseData + "," + strings.Replace(responseData, "Australia", "New Zealand", 1) + "]"))
+		case "/australia,azzz":
+			w.Write([]byte("[" + responseData + `,{"message":"Country not found or doesn't have any historical data"}]`))
+		case "/australia,bzzz":
+			w.Write([]byte("[" + responseData + "]"))
+		case "/australia":
+			w.Write([]byte(responseData))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Country not found or doesn't have any historical data"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL + "/%v%v")
+	day := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+
 	tests := []struct {
-		in          string
-		expected    string
-		expectError bool
+		countries   []string
+		expected    []string
+		expectedErr string
 	}{
-		{in: `{"message": "hello world"}`, expected: "hello world", expectError: false},
-		{in: `"good bye"}`, expected: "", expectError: true},
-		{in: `{"no messsage": "hello world"}`, expected: "", expectError: false},
+		{countries: []string{"australia", "new zealand"}, expected: []string{"Australia", "New Zealand"}},
+		{countries: []string{"australia"}, expected: []string{"Australia"}},
+		{countries: []string{"australia", "azzz"}, expected: []string{"Australia"}, expectedErr: "azzz: Country not found or doesn't have any historical data"},
+		{countries: []string{"australia", "bzzz"}, expected: []string{"Australia"}, expectedErr: "bzzz: Country not found or doesn't have any historical data"},
+		{countries: []string{"azzz", "bzzz"}, expectedErr: "Country not found or doesn't have any historical data"},
+	}
+
+	for _, test := range tests {
+		res, err := client.GetBatch(test.countries, day, day, false)
+		if test.expectedErr != "" {
+			assert.EqualError(err, test.expectedErr)
+		} else {
+			assert.NoError(err)
+		}
+		var countries []string
+		for _, r := range res {
+			countries = append(countries, r.Country)
+			assert.Equal([]Day{{Country: r.Country, Date: day, Cases: 29239, Deaths: 909, Recovered: 22991}}, r.TimeSeries.Data)
+		}
+		assert.Equal(test.expected, countries)
+	}
+}
+
+var globalResponseData = `{
+	"cases":{"3/24/21":124500000,"3/25/21":125000000},
+	"deaths":{"3/24/21":2740000,"3/25/21":2750000},
+	"recovered":{"3/24/21":70500000,"3/25/21":71000000}
+}`
+
+func TestGetGlobal(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.HasPrefix(r.URL.Path, "/all") {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"country not found"}`))
+			return
+		}
+		w.Write([]byte(globalResponseData))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL + "/%v%v")
+	expected := []Day{{Country: "Global", Date: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC), Cases: 125000000, Deaths: 2750000, Recovered: 71000000}}
+
+	res, err := client.GetGlobal(time.Now(), time.Now(), true)
+	assert.NoError(err)
+	assert.Equal(expected, res.TimeSeries.Data)
+
+	for _, name := range []string{"global", "Global", "all"} {
+		res, err = client.Get(name, time.Now(), time.Now(), true)
+		assert.NoError(err)
+		assert.Equal(expected, res.TimeSeries.Data)
 	}
+}
+
+func TestIsGlobal(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(IsGlobal("all"))
+	assert.True(IsGlobal(" Global "))
+	assert.False(IsGlobal("australia"))
+}
+
+func TestUnmarshalAPIResponse(t *testing.T) {
+	assert := assert.New(t)
+
+	var res APIResponse
+	assert.NoError(json.Unmarshal([]byte(responseData), &res))
+	assert.Equal("Australia", res.Country)
+	assert.Equal(29239, res.RawData.Cases["3/25/21"])
+
+	res = APIResponse{}
+	assert.NoError(json.Unmarshal([]byte(globalResponseData), &res))
+	assert.Equal("", res.Country)
+	assert.Equal(125000000, res.RawData.Cases["3/25/21"])
+
+	assert.Error(json.Unmarshal([]byte(`["cases"]`), &res))
+}
+
+func TestGetProvinces(t *testing.T) {
+	assert := assert.New(t)
+
+	province := func(name string) string {
+		return strings.Replace(responseData, `"province":[`, `"province":"`+name+`","provinces":[`, 1)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch strings.TrimRight(r.URL.Path, "0123456789") {
+		case "/australia/victoria,queensland":
+			w.Write([]byte("[" + province("victoria") + "," + province("queensland") + "]"))
+		case "/australia/victoria":
+			w.Write([]byte(province("victoria")))
+		case "/australia/victoria,azzz":
+			w.Write([]byte("[" + province("victoria") + `,{"message":"Province not found"}]`))
+		case "/australia":
+			w.Write([]byte(responseData))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Country not found or doesn't have any historical data"}`))
+		}
+	}))
+	defer server.Close()
 
+	client := NewClient(server.URL + "/%v%v")
+	day := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		provinces   []string
+		expected    []string
+		expectedErr string
+	}{
+		{provinces: []string{"victoria", "queensland"}, expected: []string{"victoria", "queensland"}},
+		{provinces: []string{"victoria"}, expected: []string{"victoria"}},
+		{provinces: []string{"victoria", "azzz"}, expected: []string{"victoria"}, expectedErr: "azzz: Province not found"},
+	}
 	for _, test := range tests {
-		mockResp := &http.Response{
-			Body: ioutil.NopCloser(strings.NewReader(test.in)),
+		res, err := client.GetProvinces("australia", test.provinces, day, day, false)
+		if test.expectedErr != "" {
+			assert.EqualError(err, test.expectedErr)
+		} else {
+			assert.NoError(err)
+		}
+		var provinces []string
+		for _, r := range res {
+			provinces = append(provinces, r.Province[0])
+			assert.Equal([]Day{{Country: "Australia", Province: r.Province[0], Date: day, Cases: 29239, Deaths: 909, Recovered: 22991}}, r.TimeSeries.Data)
 		}
-		err := parseErrorMessage(mockResp)
+		assert.Equal(test.expected, provinces)
+	}
+
+	series, err := client.ProvinceSeries("australia", []string{"victoria", "queensland"}, day, day, false)
+	assert.NoError(err)
+	assert.Len(series, 2)
+
+	provinces, err := client.Provinces("australia")
+	assert.NoError(err)
+	assert.Len(provinces, 8)
+	assert.Equal("victoria", provinces[6])
+}
+
+func TestUnmarshalProvinces(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		in          string
+		expected    Provinces
+		expectError bool
+	}{
+		{in: `"victoria"`, expected: Provinces{"victoria"}},
+		{in: `["victoria","queensland"]`, expected: Provinces{"victoria", "queensland"}},
+		{in: `null`, expected: nil},
+		{in: `""`, expected: nil},
+		{in: `{"victoria":1}`, expectError: true},
+	}
+	for _, test := range tests {
+		var provinces Provinces
+		err := json.Unmarshal([]byte(test.in), &provinces)
 		if test.expectError {
 			assert.Error(err)
 			continue
 		}
-		assert.EqualError(err, test.expected)
+		assert.NoError(err)
+		assert.Equal(test.expected, provinces)
 	}
 }
+
+func TestPrintProvinces(t *testing.T) {
+	assert := assert.New(t)
+	buf := new(bytes.Buffer)
+	Provinces{"victoria", "queensland"}.Print(buf, "csv")
+	assert.Equal("Province\nvictoria\nqueensland\n", buf.String())
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+//Continent a continent and its member countries from the server
+type Continent struct {
+	Continent string   `json:"continent"`
+	Countries []string `json:"countries"`
+}
+
+//Continent queries the server for the member countries of a continent
+func (c *APIClient) Continent(name string) (Continent, error) {
+	return c.ContinentContext(context.Background(), name)
+}
+
+//ContinentContext queries the server for the member countries of a continent, giving up when ctx is cancelled
+func (c *APIClient) ContinentContext(ctx context.Context, name string) (Continent, error) {
+	var data Continent
+	err := c.getJSONContext(ctx, c.BaseURL+"/continents/"+url.PathEscape(name), &data)
+	return data, err
+}
+
+//ContinentSeries sums the series of every country of the continent, fetched from source with at most workers
+//requests in flight. Countries without data are left out of the sum and reported in a SeriesError
+func ContinentSeries(source DataSource, continent Continent, from, to time.Time, latest bool, workers int) (TimeSeries, error) {
+	return ContinentSeriesContext(context.Background(), source, continent, from, to, latest, workers)
+}
+
+//ContinentSeriesContext sums the series of the countries of the continent like ContinentSeries. Once ctx is
+//cancelled ctx.Err() is returned without a sum
+func ContinentSeriesContext(ctx context.Context, source DataSource, continent Continent, from, to time.Time, latest bool, workers int) (TimeSeries, error) {
+	// the latest day is taken from the sum, as the countries may not all have reported that day
+	fetchTo := to
+	if latest {
+		fetchTo = time.Now()
+	}
+	series, err := FetchAllContext(ctx, source, continent.Countries, from, fetchTo, false, workers)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return TimeSeries{}, ctxErr
+	}
+	ts := Sum(continent.Continent, series...)
+	ts.Filter(from, to, latest)
+	return ts, err
+}
+
+//Sum adds up the series by date into a single series for country
+func Sum(country string, series ...TimeSeries) TimeSeries {
+	days := map[time.Time]int{}
+	var ts TimeSeries
+	for _, s := range series {
+		for _, obs := range s.Data {
+			i, ok := days[obs.Date]
+			if !ok {
+				i = len(ts.Data)
+				days[obs.Date] = i
+				ts.Data = append(ts.Data, Day{Country: country, Date: obs.Date})
+			}
+			ts.Data[i].Cases += obs.Cases
+			ts.Data[i].Deaths += obs.Deaths
+			ts.Data[i].Recovered += obs.Recovered
+			ts.Data[i].Tests += obs.Tests
+			ts.Data[i].Hospitalised += obs.Hospitalised
+			ts.Data[i].Doses += obs.Doses
+		}
+	}
+	ts.Order()
+	return ts
+}
@@ -0,0 +1,84 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContinent(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/continents/oceania" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Continent not found or doesn't have any cases"}`))
+			return
+		}
+		w.Write([]byte(`{"continent":"Australia-Oceania","cases":32000,"countries":["Australia","New Zealand"]}`))
+	}))
+	defer server.Close()
+
+	var source ContinentSource = NewClient(server.URL + "/historical/%v?lastdays=%v")
+	continent, err := source.ContinentContext(context.Background(), "oceania")
+	assert.NoError(err)
+	assert.Equal(Continent{Continent: "Australia-Oceania", Countries: []string{"Australia", "New Zealand"}}, continent)
+
+	_, err = source.ContinentContext(context.Background(), "atlantis")
+	assert.EqualError(err, "Continent not found or doesn't have any cases")
+}
+
+func TestContinentSeries(t *testing.T) {
+	assert := assert.New(t)
+	source := NewJHUSource("testdata/jhu")
+	day := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+
+	ts, err := ContinentSeries(source, Continent{Continent: "Oceania", Countries: []string{"Australia", "New Zealand"}}, time.Time{}, time.Time{}, true, 2)
+	assert.NoError(err)
+	assert.Equal([]Day{{Country: "Oceania", Date: day, Cases: 28200, Deaths: 903, Recovered: 22791}}, ts.Data)
+
+	ts, err = ContinentSeries(source, Continent{Continent: "Oceania", Countries: []string{"Australia", "Fiji"}}, day, day, false, 2)
+	assert.EqualError(err, "Fiji: country not found: Fiji")
+	assert.Equal([]Day{{Country: "Oceania", Date: day, Cases: 25718, Deaths: 877, Recovered: 22791}}, ts.Data)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ts, err = ContinentSeriesContext(ctx, source, Continent{Continent: "Oceania", Countries: []string{"Australia"}}, day, day, false, 2)
+	assert.Equal(context.Canceled, err)
+	assert.Empty(ts.Data)
+}
+
+func TestSum(t *testing.T) {
+	assert := assert.New(t)
+	day := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := Sum("Both",
+		TimeSeries{Data: []Day{{Country: "a", Date: day.AddDate(0, 0, 1), Cases: 2, Deaths: 1}, {Country: "a", Date: day, Cases: 1, Doses: 5}}},
+		TimeSeries{Data: []Day{{Country: "b", Date: day, Cases: 10, Recovered: 3, Tests: 4, Hospitalised: 6}}},
+	)
+	assert.Equal([]Day{
+		{Country: "Both", Date: day, Cases: 11, Recovered: 3, Tests: 4, Hospitalised: 6, Doses: 5},
+		{Country: "Both", Date: day.AddDate(0, 0, 1), Cases: 2, Deaths: 1},
+	}, ts.Data)
+	assert.Empty(Sum("None").Data)
+}
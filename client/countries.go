@@ -0,0 +1,211 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+var countriesHeader = []string{"Country", "ISO2", "ISO3", "Continent", "Provinces"}
+
+//CountryListing a country a data source has data for. The codes and continent come from the country table,
+//so are empty for anything which isn't a country (e.g. a cruise ship)
+type CountryListing struct {
+	Country   string
+	ISO2      string
+	ISO3      string
+	Continent string
+	Provinces bool // Whether the source has data for the provinces (or states) of the country
+}
+
+//CountryListings the countries of a data source
+type CountryListings []CountryListing
+
+//Print print the countries to an os.File
+func (l CountryListings) Print(output io.Writer, format string) {
+	var strData [][]string
+	for _, c := range l {
+		provinces := "no"
+		if c.Provinces {
+			provinces = "yes"
+		}
+		strData = append(strData, []string{c.Country, c.ISO2, c.ISO3, c.Continent, provinces})
+	}
+	writeTable(strData, countriesHeader, output, format)
+}
+
+//newCountryListings lists the countries (with whether they have provinces) in alphabetical order, filling in
+//the codes and continent from the country table. Each name is looked up by its ISO3 code if it has one
+func newCountryListings(names []string, iso3 map[string]string, provinces map[string]bool) (CountryListings, error) {
+	resolver, err := NewResolver()
+	if err != nil {
+		return nil, err
+	}
+	var listings CountryListings
+	for _, name := range names {
+		listing := CountryListing{Country: name, Provinces: provinces[name]}
+		key := name
+		if code := iso3[name]; code != "" {
+			key = code
+		}
+		if country, ok := resolver.Lookup(key); ok {
+			listing.ISO2, listing.ISO3, listing.Continent = country.ISO2, country.ISO3, country.Continent
+		}
+		listings = append(listings, listing)
+	}
+	sort.SliceStable(listings, func(i, j int) bool {
+		return strings.ToLower(listings[i].Country) < strings.ToLower(listings[j].Country)
+	})
+	return listings, nil
+}
+
+//Countries lists the countries the disease.sh api has historical data for, from the last day of every country
+func (c *APIClient) Countries() (CountryListings, error) {
+	return c.CountriesContext(context.Background())
+}
+
+//CountriesContext lists the countries the disease.sh api has historical data for, giving up when ctx is cancelled
+func (c *APIClient) CountriesContext(ctx context.Context) (CountryListings, error) {
+	var res []APIResponse
+	err := c.getJSONContext(ctx, c.lastDaysURL("/historical", time.Now()), &res)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	provinces := map[string]bool{}
+	for _, r := range res {
+		if _, ok := provinces[r.Country]; !ok {
+			names = append(names, r.Country)
+		}
+		provinces[r.Country] = provinces[r.Country] || len(r.Province) > 0
+	}
+	return newCountryListings(names, nil, provinces)
+}
+
+//Countries lists the countries in the confirmed cases file
+func (s *JHUSource) Countries() (CountryListings, error) {
+	return s.CountriesContext(context.Background())
+}
+
+//CountriesContext lists the countries in the confirmed cases file like Countries, unless ctx is already cancelled
+func (s *JHUSource) CountriesContext(ctx context.Context) (CountryListings, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var names []string
+	provinces := map[string]bool{}
+	err := s.scan("confirmed", func(provinceName, countryName string, header, record []string) error {
+		if _, ok := provinces[countryName]; !ok {
+			names = append(names, countryName)
+		}
+		provinces[countryName] = provinces[countryName] || provinceName != ""
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newCountryListings(names, nil, provinces)
+}
+
+//Countries lists the locations in the OWID file, leaving out the OWID_ aggregates such as continents and the world
+func (s *OWIDSource) Countries() (CountryListings, error) {
+	return s.CountriesContext(context.Background())
+}
+
+//CountriesContext lists the locations in the OWID file, giving up on a download when ctx is cancelled
+func (s *OWIDSource) CountriesContext(ctx context.Context) (CountryListings, error) {
+	r, err := s.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var locations map[string]string
+	if strings.HasSuffix(strings.ToLower(s.Path), ".json") {
+		locations, err = readOWIDJSONLocations(r)
+	} else {
+		locations, err = readOWIDCSVLocations(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	iso3 := map[string]string{}
+	for code, name := range locations {
+		if strings.HasPrefix(code, "OWID_") {
+			continue
+		}
+		names = append(names, name)
+		iso3[name] = code
+	}
+	return newCountryListings(names, iso3, nil)
+}
+
+//readOWIDJSONLocations the location of each iso code in the OWID json file
+func readOWIDJSONLocations(r io.Reader) (map[string]string, error) {
+	var data map[string]struct {
+		Location string `json:"location"`
+	}
+	err := json.NewDecoder(r).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	locations := map[string]string{}
+	for code, location := range data {
+		locations[code] = location.Location
+	}
+	return locations, nil
+}
+
+//readOWIDCSVLocations the location of each iso code in the OWID csv file
+func readOWIDCSVLocations(r io.Reader) (map[string]string, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	isoCol, locationCol := -1, -1
+	for i, col := range header {
+		switch strings.TrimPrefix(col, "\ufeff") {
+		case "iso_code":
+			isoCol = i
+		case "location":
+			locationCol = i
+		}
+	}
+	if isoCol < 0 || locationCol < 0 {
+		return nil, ErrorBadCSVHeader
+	}
+	locations := map[string]string{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return locations, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		locations[record[isoCol]] = record[locationCol]
+	}
+}
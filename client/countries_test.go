@@ -0,0 +1,100 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var historicalData = `[
+	{"country":"Australia","province":"new south wales","timeline":{"cases":{"3/25/21":5111},"deaths":{"3/25/21":54},"recovered":{"3/25/21":3109}}},
+	{"country":"Australia","province":"victoria","timeline":{"cases":{"3/25/21":20483},"deaths":{"3/25/21":820},"recovered":{"3/25/21":19563}}},
+	{"country":"USA","province":null,"timeline":{"cases":{"3/25/21":30079282},"deaths":{"3/25/21":546822},"recovered":{"3/25/21":0}}},
+	{"country":"Diamond Princess","province":null,"timeline":{"cases":{"3/25/21":712},"deaths":{"3/25/21":13},"recovered":{"3/25/21":699}}}
+]`
+
+func TestCountries(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/historical" || r.URL.Query().Get("lastdays") != "1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(historicalData))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		source   CountrySource
+		expected CountryListings
+	}{
+		{
+			source: NewClient(server.URL + "/historical/%v?lastdays=%v"),
+			expected: CountryListings{
+				{Country: "Australia", ISO2: "AU", ISO3: "AUS", Continent: "Australia-Oceania", Provinces: true},
+				{Country: "Diamond Princess"},
+				{Country: "USA", ISO2: "US", ISO3: "USA", Continent: "North America"},
+			},
+		},
+		{
+			source: NewJHUSource("testdata/jhu"),
+			expected: CountryListings{
+				{Country: "Australia", ISO2: "AU", ISO3: "AUS", Continent: "Australia-Oceania", Provinces: true},
+				{Country: "New Zealand", ISO2: "NZ", ISO3: "NZL", Continent: "Australia-Oceania"},
+			},
+		},
+		{
+			source: NewOWIDSource("testdata/owid/owid-covid-data.csv"),
+			expected: CountryListings{
+				{Country: "Australia", ISO2: "AU", ISO3: "AUS", Continent: "Australia-Oceania"},
+				{Country: "New Zealand", ISO2: "NZ", ISO3: "NZL", Continent: "Australia-Oceania"},
+			},
+		},
+		{
+			source: NewOWIDSource("testdata/owid/owid-covid-data.json"),
+			expected: CountryListings{
+				{Country: "Australia", ISO2: "AU", ISO3: "AUS", Continent: "Australia-Oceania"},
+				{Country: "New Zealand", ISO2: "NZ", ISO3: "NZL", Continent: "Australia-Oceania"},
+			},
+		},
+	}
+	for _, test := range tests {
+		countries, err := test.source.CountriesContext(context.Background())
+		assert.NoError(err)
+		assert.Equal(test.expected, countries)
+	}
+
+	_, err := NewJHUSource("testdata/missing").Countries()
+	assert.Error(err)
+}
+
+func TestPrintCountries(t *testing.T) {
+	assert := assert.New(t)
+	buf := new(bytes.Buffer)
+	CountryListings{
+		{Country: "Australia", ISO2: "AU", ISO3: "AUS", Continent: "Australia-Oceania", Provinces: true},
+		{Country: "Diamond Princess"},
+	}.Print(buf, "csv")
+	assert.Equal("Country,ISO2,ISO3,Continent,Provinces\nAustralia,AU,AUS,Australia-Oceania,yes\nDiamond Princess,,,,no\n", buf.String())
+}
@@ -0,0 +1,143 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//isoLayouts the ISO-8601 dates and times accepted, anything after the date is dropped
+var isoLayouts = []string{
+	"2006-01-02",
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+//parseDate parses a date from a timeline, which is either M/D/YY (as disease.sh uses), M/D/YYYY,
+//ISO-8601 or a unix timestamp in milliseconds. The date is returned at midnight UTC
+func parseDate(date string) (time.Time, error) {
+	date = strings.TrimSpace(date)
+	if strings.Count(date, "/") == 2 {
+		return parseSlashDate(date)
+	}
+	if ms, err := strconv.ParseInt(date, 10, 64); err == nil && len(date) >= 10 {
+		return midnight(time.Unix(0, ms*int64(time.Millisecond)).UTC()), nil
+	}
+	for _, layout := range isoLayouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			return midnight(t), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %q", ErrorBadDateFormat, date)
+}
+
+//parseSlashDate parses M/D/YY or M/D/YYYY, where a two digit year is in this century
+func parseSlashDate(date string) (time.Time, error) {
+	parts := strings.Split(date, "/")
+	var numbers [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || len(part) > 4 || (i < 2 && len(part) > 2) {
+			return time.Time{}, fmt.Errorf("%w: %q", ErrorBadDateFormat, date)
+		}
+		numbers[i] = n
+	}
+	month, day, year := numbers[0], numbers[1], numbers[2]
+	switch len(parts[2]) {
+	case 2:
+		year += 2000
+	case 4:
+	default:
+		return time.Time{}, fmt.Errorf("%w: %q", ErrorBadDateFormat, date)
+	}
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	// time.Date moves an impossible date (e.g. 2/30) on to the next month
+	if t.Month() != time.Month(month) || t.Day() != day {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrorBadDateFormat, date)
+	}
+	return t, nil
+}
+
+//midnight the start of the day of t, keeping the date t has in its own time zone
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+//BadDatesError the keys of a timeline which aren't dates. The days which are dates are returned along with
+//it, so it is only a warning. It matches ErrorBadDateFormat
+type BadDatesError struct {
+	Keys []string
+}
+
+func (e *BadDatesError) Error() string {
+	quoted := make([]string, len(e.Keys))
+	for i, key := range e.Keys {
+		quoted[i] = strconv.Quote(key)
+	}
+	return fmt.Sprintf("%v: %v", ErrorBadDateFormat, strings.Join(quoted, ", "))
+}
+
+func (e *BadDatesError) Unwrap() error {
+	return ErrorBadDateFormat
+}
+
+//badDatesError a BadDatesError naming every key of a timeline which isn't a date, or nil if they all are. When
+//none of the days of the timeline were dates there is nothing to return along with it, so it is a plain error
+func badDatesError(keys []string, days int) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	err := &BadDatesError{Keys: keys}
+	if days == 0 {
+		return fmt.Errorf("no dates in the timeline: %w", err)
+	}
+	return err
+}
+
+//SplitBadDates splits the countries of a SeriesError whose series were still returned, as only some keys of
+//their timelines weren't dates, from the countries which failed. Any other error is returned as is
+func SplitBadDates(err error) (SeriesError, error) {
+	warnings := SeriesError{}
+	failed, ok := err.(SeriesError)
+	if !ok {
+		return warnings, err
+	}
+	rest := SeriesError{}
+	for country, countryErr := range failed {
+		if partialSeries(countryErr) {
+			warnings[country] = countryErr
+		} else {
+			rest[country] = countryErr
+		}
+	}
+	if len(rest) == 0 {
+		return warnings, nil
+	}
+	return warnings, rest
+}
+
+//partialSeries whether err comes along with a series which still has the days which were dates
+func partialSeries(err error) bool {
+	_, ok := err.(*BadDatesError)
+	return ok
+}
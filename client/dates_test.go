@@ -0,0 +1,133 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDate(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		in          string
+		expected    time.Time
+		expectedErr error
+	}{
+		{in: "21-1-1", expected: time.Time{}, expectedErr: ErrorBadDateFormat},
+		{in: "1/1/21", expected: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), expectedErr: nil},
+		{in: "12/31/20", expected: time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{in: "3/25/2021", expected: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)},
+		{in: "2021-03-25", expected: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)},
+		{in: "2021-03-25T23:30:00+10:00", expected: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)},
+		{in: "2021-03-25T10:00:00Z", expected: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)},
+		{in: "2021-03-25 10:00:00", expected: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)},
+		{in: "1616716800000", expected: time.Date(2021, 3, 26, 0, 0, 0, 0, time.UTC)},
+		{in: "1616716799999", expected: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)},
+		{in: "2/30/21", expectedErr: ErrorBadDateFormat},
+		{in: "3/25/221", expectedErr: ErrorBadDateFormat},
+		{in: "a/b/21", expectedErr: ErrorBadDateFormat},
+		{in: "20210325", expectedErr: ErrorBadDateFormat},
+		{in: "Lat", expectedErr: ErrorBadDateFormat},
+		{in: "", expectedErr: ErrorBadDateFormat},
+	}
+
+	for _, test := range tests {
+		formatted, err := parseDate(test.in)
+		if test.expectedErr != nil {
+			assert.True(errors.Is(err, test.expectedErr), test.in)
+		} else {
+			assert.NoError(err)
+		}
+		assert.Equal(test.expected, formatted, test.in)
+	}
+	_, err := parseDate("tomorrow")
+	assert.EqualError(err, `Incorrect Date Format: "tomorrow"`)
+}
+
+func TestFormatResponseBadDates(t *testing.T) {
+	assert := assert.New(t)
+	day := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+	res := APIResponse{
+		Country: "Australia",
+		RawData: RawData{
+			Cases:  map[string]int{"3/24/21": 29230, "2021-03-25": 29239, "yesterday": 1, "soon": 2},
+			Deaths: map[string]int{"3/24/21": 909, "2021-03-25": 909},
+		},
+	}
+	err := res.FormatResponse(day.AddDate(0, 0, -1), day, false)
+	assert.EqualError(err, `Incorrect Date Format: "soon", "yesterday"`)
+	assert.True(errors.Is(err, ErrorBadDateFormat))
+	assert.Equal([]Day{
+		{Country: "Australia", Date: day.AddDate(0, 0, -1), Cases: 29230, Deaths: 909},
+		{Country: "Australia", Date: day, Cases: 29239, Deaths: 909},
+	}, res.TimeSeries.Data)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"country":"Australia","timeline":{"cases":{"3/25/21":29239,"Mar 24":29230},"deaths":{},"recovered":{}}}`))
+	}))
+	defer server.Close()
+	data, err := NewClient(server.URL+"/historical/%v?lastdays=%v").Get("australia", day, day, false)
+	assert.EqualError(err, `Incorrect Date Format: "Mar 24"`)
+	assert.Len(data.TimeSeries.Data, 1)
+}
+
+func TestFetchBadDatesKeepsValidDays(t *testing.T) {
+	assert := assert.New(t)
+	day := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/historical/australia":
+			w.Write([]byte(`{"country":"Australia","timeline":{"cases":{"3/25/21":29239,"Mar 24":29230},"deaths":{"3/25/21":909},"recovered":{}}}`))
+		case "/historical/fiji":
+			w.Write([]byte(`{"country":"Fiji","timeline":{"cases":{"Mar 25":68},"deaths":{},"recovered":{}}}`))
+		case "/historical/australia,new zealand":
+			w.Write([]byte(`[{"country":"Australia","timeline":{"cases":{"3/25/21":29239,"Mar 24":29230},"deaths":{"3/25/21":909},"recovered":{}}},` +
+				`{"country":"New Zealand","timeline":{"cases":{"3/25/21":2475},"deaths":{"3/25/21":26},"recovered":{}}}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c := NewClient(server.URL + "/historical/%v?lastdays=%v")
+
+	// a single country at a time
+	series, err := FetchAll(struct{ DataSource }{c}, []string{"australia", "fiji"}, day, day, false, 1)
+	assert.Equal([]TimeSeries{{Data: []Day{{Country: "Australia", Date: day, Cases: 29239, Deaths: 909}}}}, series)
+	warnings, err := SplitBadDates(err)
+	assert.EqualError(warnings, `australia: Incorrect Date Format: "Mar 24"`)
+	assert.EqualError(err, `fiji: no dates in the timeline: Incorrect Date Format: "Mar 25"`)
+	assert.True(errors.Is(err, ErrorBadDateFormat))
+
+	// and in a batch
+	series, err = FetchAll(c, []string{"australia", "new zealand"}, day, day, false, 1)
+	assert.Len(series, 2)
+	assert.Equal(29239, series[0].Data[0].Cases)
+	assert.Equal(2475, series[1].Data[0].Cases)
+	warnings, err = SplitBadDates(err)
+	assert.Contains(warnings, "australia")
+	assert.NoError(err)
+
+	warnings, err = SplitBadDates(ErrCountryNotFound)
+	assert.Empty(warnings)
+	assert.Equal(ErrCountryNotFound, err)
+}
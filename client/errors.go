@@ -0,0 +1,101 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+var (
+	ErrCountryNotFound = errors.New("country not found") //The data source has no data for the country (or province, state or continent)
+	ErrRateLimited     = errors.New("rate limited")      //The server answered 429 Too Many Requests
+)
+
+//UpstreamError a response from the server other than 200 OK. It matches ErrCountryNotFound for a 404 and
+//ErrRateLimited for a 429 with errors.Is
+type UpstreamError struct {
+	StatusCode int
+	Body       string
+	URL        string
+	Message    string // The message of a json body, if it had one
+}
+
+func (e *UpstreamError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	status := fmt.Sprintf("%v %v", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.URL == "" {
+		return status
+	}
+	return fmt.Sprintf("%v from %v", status, e.URL)
+}
+
+//Is matches the status code against ErrCountryNotFound and ErrRateLimited
+func (e *UpstreamError) Is(target error) bool {
+	switch target {
+	case ErrCountryNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+//Temporary whether the same request might work later on
+func (e *UpstreamError) Temporary() bool {
+	return temporary(e.StatusCode)
+}
+
+//temporary whether a response with status is worth trying again
+func temporary(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+//notFoundError a message from the data source about a missing country, which matches ErrCountryNotFound
+type notFoundError struct {
+	message string
+}
+
+func (e *notFoundError) Error() string {
+	return e.message
+}
+
+func (e *notFoundError) Is(target error) bool {
+	return target == ErrCountryNotFound
+}
+
+//parseErrorMessage turns a response other than 200 OK into an UpstreamError, with the message of the body if it is json
+func parseErrorMessage(resp *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	upstream := &UpstreamError{StatusCode: resp.StatusCode, Body: string(body)}
+	if resp.Request != nil && resp.Request.URL != nil {
+		upstream.URL = resp.Request.URL.String()
+	}
+	var errMessage struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &errMessage) == nil {
+		upstream.Message = errMessage.Message
+	}
+	return upstream
+}
@@ -0,0 +1,121 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseErrorMessage(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		in          string
+		status      int
+		expected    string
+		notFound    bool
+		rateLimited bool
+	}{
+		{in: `{"message": "hello world"}`, status: http.StatusNotFound, expected: "hello world", notFound: true},
+		{in: `"good bye"}`, status: http.StatusBadGateway, expected: "502 Bad Gateway from http://localhost/historical/australia"},
+		{in: `{"no messsage": "hello world"}`, status: http.StatusTooManyRequests, expected: "429 Too Many Requests from http://localhost/historical/australia", rateLimited: true},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost/historical/australia", nil)
+		mockResp := &http.Response{
+			StatusCode: test.status,
+			Body:       ioutil.NopCloser(strings.NewReader(test.in)),
+			Request:    req,
+		}
+		err := parseErrorMessage(mockResp)
+		assert.EqualError(err, test.expected)
+		assert.Equal(test.notFound, errors.Is(err, ErrCountryNotFound))
+		assert.Equal(test.rateLimited, errors.Is(err, ErrRateLimited))
+
+		var upstream *UpstreamError
+		assert.True(errors.As(err, &upstream))
+		assert.Equal(test.status, upstream.StatusCode)
+		assert.Equal(test.in, upstream.Body)
+		assert.Equal("http://localhost/historical/australia", upstream.URL)
+		assert.Equal(test.status != http.StatusNotFound, upstream.Temporary())
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/historical/australia,"):
+			w.Write([]byte("[" + responseData + `,{"message":"Country not found or doesn't have any historical data"}]`))
+		case r.URL.Path == "/historical/busy":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Country not found or doesn't have any historical data"}`))
+		}
+	}))
+	defer server.Close()
+	c := NewClient(server.URL + "/historical/%v?lastdays=%v")
+	c.Retry.Retries = 0
+	day := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+
+	_, err := c.Get("azzz", day, day, false)
+	assert.True(errors.Is(err, ErrCountryNotFound))
+	assert.False(errors.Is(err, ErrRateLimited))
+
+	_, err = c.Get("busy", day, day, false)
+	assert.True(errors.Is(err, ErrRateLimited))
+	var upstream *UpstreamError
+	assert.True(errors.As(err, &upstream))
+	assert.Equal(fmt.Sprintf("%v/historical/busy?lastdays=%v", server.URL, calcDays(day)), upstream.URL)
+
+	// a country missing from a batch is in a SeriesError
+	_, err = c.GetBatch([]string{"australia", "azzz"}, day, day, false)
+	assert.EqualError(err, "azzz: Country not found or doesn't have any historical data")
+	assert.True(errors.Is(err, ErrCountryNotFound))
+	assert.False(errors.As(err, &upstream))
+
+	_, err = NewJHUSource("testdata/jhu").Series("azzz", day, day, false)
+	assert.EqualError(err, "country not found: azzz")
+	assert.True(errors.Is(err, ErrCountryNotFound))
+	_, err = NewOWIDSource("testdata/owid/owid-covid-data.csv").Series("azzz", day, day, false)
+	assert.True(errors.Is(err, ErrCountryNotFound))
+}
+
+func TestSeriesErrorAs(t *testing.T) {
+	assert := assert.New(t)
+	err := error(SeriesError{
+		"b": &UpstreamError{StatusCode: http.StatusBadGateway},
+		"a": &UpstreamError{StatusCode: http.StatusTooManyRequests},
+	})
+	assert.True(errors.Is(err, ErrRateLimited))
+	assert.False(errors.Is(err, ErrCountryNotFound))
+	var upstream *UpstreamError
+	assert.True(errors.As(err, &upstream))
+	assert.Equal(http.StatusTooManyRequests, upstream.StatusCode)
+}
@@ -0,0 +1,139 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//SeriesError the countries which couldn't be fetched and why
+type SeriesError map[string]error
+
+func (e SeriesError) Error() string {
+	var messages []string
+	for _, country := range e.countries() {
+		messages = append(messages, fmt.Sprintf("%v: %v", country, e[country]))
+	}
+	return strings.Join(messages, "\n")
+}
+
+//countries the countries which failed in alphabetical order
+func (e SeriesError) countries() []string {
+	var countries []string
+	for country := range e {
+		countries = append(countries, country)
+	}
+	sort.Strings(countries)
+	return countries
+}
+
+//Is whether the error of any of the countries matches target
+func (e SeriesError) Is(target error) bool {
+	for _, country := range e.countries() {
+		if errors.Is(e[country], target) {
+			return true
+		}
+	}
+	return false
+}
+
+//As finds the first error (in the order of the countries) which matches target
+func (e SeriesError) As(target interface{}) bool {
+	for _, country := range e.countries() {
+		if errors.As(e[country], target) {
+			return true
+		}
+	}
+	return false
+}
+
+//FetchAll queries the source for every country with at most workers requests in flight, or with a single
+//request if the source is a BatchSource. The series are returned in the same order as countries, any
+//country which failed is left out and reported in a SeriesError. A country whose timeline had some keys which
+//aren't dates keeps the rest of its days and is reported too, SplitBadDates separates these from the failures
+func FetchAll(source DataSource, countries []string, from, to time.Time, latest bool, workers int) ([]TimeSeries, error) {
+	return FetchAllContext(context.Background(), source, countries, from, to, latest, workers)
+}
+
+//FetchAllContext queries the source for every country like FetchAll. Once ctx is cancelled no more countries
+//are requested, the requests in flight are cancelled and ctx.Err() is returned
+func FetchAllContext(ctx context.Context, source DataSource, countries []string, from, to time.Time, latest bool, workers int) ([]TimeSeries, error) {
+	if batch, ok := source.(BatchSource); ok && len(countries) > 1 {
+		return batch.SeriesBatchContext(ctx, countries, from, to, latest)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	results := make([]TimeSeries, len(countries))
+	errs := make([]error, len(countries))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(countries); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], errs[i] = source.SeriesContext(ctx, countries[i], from, to, latest)
+			}
+		}()
+	}
+dispatch:
+	for i := range countries {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var series []TimeSeries
+	failed := SeriesError{}
+	for i, country := range countries {
+		if errs[i] != nil {
+			failed[country] = errs[i]
+			if !partialSeries(errs[i]) {
+				continue
+			}
+		}
+		series = append(series, results[i])
+	}
+	if len(failed) > 0 {
+		return series, failed
+	}
+	return series, nil
+}
+
+//Combine joins several time series into one, keeping the order of the series
+func Combine(series ...TimeSeries) TimeSeries {
+	var combined TimeSeries
+	for _, ts := range series {
+		combined.Data = append(combined.Data, ts.Data...)
+	}
+	return combined
+}
@@ -0,0 +1,118 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//mockSource returns a single day for every country and tracks the number of concurrent calls
+type mockSource struct {
+	mu       sync.Mutex
+	inFlight int
+	maxSeen  int
+}
+
+func (m *mockSource) SeriesContext(ctx context.Context, country string, from, to time.Time, latest bool) (TimeSeries, error) {
+	m.mu.Lock()
+	m.inFlight++
+	if m.inFlight > m.maxSeen {
+		m.maxSeen = m.inFlight
+	}
+	m.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	m.mu.Lock()
+	m.inFlight--
+	m.mu.Unlock()
+
+	if country == "azzz" {
+		return TimeSeries{}, errors.New("country not found")
+	}
+	return TimeSeries{Data: []Day{{Country: country, Date: from}}}, nil
+}
+
+func TestFetchAll(t *testing.T) {
+	assert := assert.New(t)
+	day := time.Date(2021, 3, 24, 0, 0, 0, 0, time.UTC)
+
+	source := &mockSource{}
+	series, err := FetchAll(source, []string{"a", "b", "c", "d", "e"}, day, day, false, 2)
+	assert.NoError(err)
+	assert.Len(series, 5)
+	for i, country := range []string{"a", "b", "c", "d", "e"} {
+		assert.Equal(country, series[i].Data[0].Country)
+	}
+	assert.True(source.maxSeen <= 2)
+
+	series, err = FetchAll(&mockSource{}, []string{"a", "azzz", "b"}, day, day, false, 0)
+	assert.Len(series, 2)
+	assert.EqualError(err, "azzz: country not found")
+	var seriesErr SeriesError
+	assert.True(errors.As(err, &seriesErr))
+	assert.Contains(seriesErr, "azzz")
+}
+
+func TestFetchAllContext(t *testing.T) {
+	assert := assert.New(t)
+	day := time.Date(2021, 3, 24, 0, 0, 0, 0, time.UTC)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	series, err := FetchAllContext(ctx, &mockSource{}, []string{"a", "b", "c"}, day, day, false, 1)
+	assert.Equal(context.Canceled, err)
+	assert.Empty(series)
+
+	// the requests in flight are cancelled too
+	requests := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requests)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		<-requests
+		cancel()
+	}()
+	series, err = FetchAllContext(ctx, NewClient(server.URL+"/historical/%v?lastdays=%v"), []string{"australia"}, day, day, false, 1)
+	assert.True(errors.Is(err, context.Canceled))
+	assert.Empty(series)
+}
+
+func TestCombine(t *testing.T) {
+	assert := assert.New(t)
+	day := time.Date(2021, 3, 24, 0, 0, 0, 0, time.UTC)
+	combined := Combine(
+		TimeSeries{Data: []Day{{Country: "a", Date: day}, {Country: "a", Date: day.AddDate(0, 0, 1)}}},
+		TimeSeries{Data: []Day{{Country: "b", Date: day}}},
+	)
+	assert.Equal([]Day{{Country: "a", Date: day}, {Country: "a", Date: day.AddDate(0, 0, 1)}, {Country: "b", Date: day}}, combined.Data)
+}
+
+func TestSeriesError(t *testing.T) {
+	assert := assert.New(t)
+	err := SeriesError{"b": errors.New("two"), "a": errors.New("one")}
+	assert.EqualError(err, "a: one\nb: two")
+}
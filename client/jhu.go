@@ -0,0 +1,241 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//JHUSource reads the John Hopkins CSSE time_series_covid19_*_global.csv files from a local directory
+type JHUSource struct {
+	Dir string
+}
+
+var (
+	ErrorBadCSVHeader = errors.New("Incorrect CSV Header") //CSV file without the expected columns
+)
+
+//NewJHUSource returns a data source for the csv files in dir
+func NewJHUSource(dir string) *JHUSource {
+	return &JHUSource{Dir: dir}
+}
+
+//Series returns the time series for a country, summing all of its provinces. The reserved global names sum every country
+func (s *JHUSource) Series(country string, from, to time.Time, latest bool) (TimeSeries, error) {
+	return s.SeriesContext(context.Background(), country, from, to, latest)
+}
+
+//SeriesContext returns the time series for a country like Series. The files are local, so ctx is only checked
+//before they are read
+func (s *JHUSource) SeriesContext(ctx context.Context, country string, from, to time.Time, latest bool) (TimeSeries, error) {
+	if err := ctx.Err(); err != nil {
+		return TimeSeries{}, err
+	}
+	return s.provinceSeries(country, "", from, to, latest)
+}
+
+//ProvinceSeries returns a time series for each of the provinces of a country
+func (s *JHUSource) ProvinceSeries(country string, provinces []string, from, to time.Time, latest bool) ([]TimeSeries, error) {
+	return s.ProvinceSeriesContext(context.Background(), country, provinces, from, to, latest)
+}
+
+//ProvinceSeriesContext returns a time series for each of the provinces like ProvinceSeries, stopping before the
+//next province once ctx is cancelled
+func (s *JHUSource) ProvinceSeriesContext(ctx context.Context, country string, provinces []string, from, to time.Time, latest bool) ([]TimeSeries, error) {
+	var series []TimeSeries
+	failed := SeriesError{}
+	for _, province := range provinces {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ts, err := s.provinceSeries(country, province, from, to, latest)
+		if err != nil {
+			failed[province] = err
+			if !partialSeries(err) {
+				continue
+			}
+		}
+		series = append(series, ts)
+	}
+	if len(failed) > 0 {
+		return series, failed
+	}
+	return series, nil
+}
+
+//Provinces lists the provinces of a country found in the confirmed cases file
+func (s *JHUSource) Provinces(country string) (Provinces, error) {
+	return s.ProvincesContext(context.Background(), country)
+}
+
+//ProvincesContext lists the provinces of a country like Provinces, unless ctx is already cancelled
+func (s *JHUSource) ProvincesContext(ctx context.Context, country string) (Provinces, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var provinces Provinces
+	err := s.scan("confirmed", func(provinceName, countryName string, header, record []string) error {
+		if strings.EqualFold(countryName, country) && provinceName != "" {
+			provinces = append(provinces, provinceName)
+		}
+		return nil
+	})
+	return provinces, err
+}
+
+//provinceSeries returns the time series for a single province of a country. An empty province sums the whole country
+func (s *JHUSource) provinceSeries(country, province string, from, to time.Time, latest bool) (TimeSeries, error) {
+	var data APIResponse
+	var found bool
+	var err error
+
+	var provinceName string
+	data.Country, provinceName, data.RawData.Cases, found, err = s.read("confirmed", country, province)
+	if err != nil {
+		return TimeSeries{}, err
+	}
+	if !found && province != "" {
+		return TimeSeries{}, fmt.Errorf("%w: province %v/%v", ErrCountryNotFound, country, province)
+	}
+	if !found {
+		return TimeSeries{}, fmt.Errorf("%w: %v", ErrCountryNotFound, country)
+	}
+	_, _, data.RawData.Deaths, _, err = s.read("deaths", country, province)
+	if err != nil {
+		return TimeSeries{}, err
+	}
+	// JHU stopped publishing recoveries, so a missing file is not an error
+	_, _, data.RawData.Recovered, _, err = s.read("recovered", country, province)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return TimeSeries{}, err
+	}
+
+	err = data.FormatResponse(from, to, latest)
+	for i := range data.TimeSeries.Data {
+		data.TimeSeries.Data[i].Province = provinceName
+	}
+	return data.TimeSeries, err
+}
+
+//read sums the matching rows of a single csv file into a date keyed map
+func (s *JHUSource) read(kind, country, province string) (string, string, map[string]int, bool, error) {
+	values := map[string]int{}
+	var name, provinceName string
+	var found bool
+	global := IsGlobal(country)
+	err := s.scan(kind, func(rowProvince, rowCountry string, header, record []string) error {
+		if !global && !strings.EqualFold(rowCountry, country) {
+			return nil
+		}
+		if province != "" && !strings.EqualFold(rowProvince, province) {
+			return nil
+		}
+		name, found = rowCountry, true
+		if global {
+			name = "Global"
+		}
+		if province != "" {
+			provinceName = rowProvince
+		}
+		for col, date := range header {
+			if col >= len(record) || record[col] == "" {
+				continue
+			}
+			value, err := strconv.ParseFloat(record[col], 64)
+			if err != nil {
+				return err
+			}
+			values[date] += int(value)
+		}
+		return nil
+	})
+	return name, provinceName, values, found, err
+}
+
+//scan calls fn with the province, country and date values of every row in a csv file. The header
+//and record passed to fn only hold the date columns
+func (s *JHUSource) scan(kind string, fn func(province, country string, header, record []string) error) error {
+	f, err := os.Open(filepath.Join(s.Dir, fmt.Sprintf("time_series_covid19_%v_global.csv", kind)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return err
+	}
+	provinceCol, countryCol, dateCols := jhuColumns(header)
+	if provinceCol < 0 || countryCol < 0 {
+		return ErrorBadCSVHeader
+	}
+	dates := make([]string, len(dateCols))
+	for i, col := range dateCols {
+		dates[i] = header[col]
+	}
+
+	values := make([]string, len(dateCols))
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for i, col := range dateCols {
+			values[i] = ""
+			if col < len(record) {
+				values[i] = record[col]
+			}
+		}
+		err = fn(record[provinceCol], record[countryCol], dates, values)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+//jhuColumns finds the province, country and date columns of a JHU csv header
+func jhuColumns(header []string) (int, int, []int) {
+	provinceCol, countryCol := -1, -1
+	var dateCols []int
+	for i, col := range header {
+		switch strings.TrimPrefix(col, "\ufeff") {
+		case "Province/State":
+			provinceCol = i
+		case "Country/Region":
+			countryCol = i
+		default:
+			if _, err := parseDate(col); err == nil {
+				dateCols = append(dateCols, i)
+			}
+		}
+	}
+	return provinceCol, countryCol, dateCols
+}
@@ -0,0 +1,121 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJHUProvinceSeries(t *testing.T) {
+	assert := assert.New(t)
+	source := NewJHUSource("testdata/jhu")
+
+	tests := []struct {
+		country     string
+		province    string
+		from        time.Time
+		to          time.Time
+		latest      bool
+		expected    []Day
+		expectedErr string
+	}{
+		{
+			country: "australia",
+			from:    time.Date(2021, 3, 24, 0, 0, 0, 0, time.UTC),
+			to:      time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC),
+			expected: []Day{
+				{Country: "Australia", Date: time.Date(2021, 3, 24, 0, 0, 0, 0, time.UTC), Cases: 25709, Deaths: 877, Recovered: 22783},
+				{Country: "Australia", Date: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC), Cases: 25718, Deaths: 877, Recovered: 22791},
+			},
+		},
+		{
+			country:  "Australia",
+			province: "victoria",
+			latest:   true,
+			expected: []Day{
+				{Country: "Australia", Province: "Victoria", Date: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC), Cases: 20483, Deaths: 820, Recovered: 19563},
+			},
+		},
+		{
+			country: "new zealand",
+			latest:  true,
+			expected: []Day{
+				{Country: "New Zealand", Date: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC), Cases: 2482, Deaths: 26},
+			},
+		},
+		{
+			country: "global",
+			latest:  true,
+			expected: []Day{
+				{Country: "Global", Date: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC), Cases: 28200, Deaths: 903, Recovered: 22791},
+			},
+		},
+		{country: "azzz", latest: true, expectedErr: "country not found: azzz"},
+		{country: "australia", province: "tasmania", latest: true, expectedErr: "country not found: province australia/tasmania"},
+	}
+
+	for _, test := range tests {
+		ts, err := source.provinceSeries(test.country, test.province, test.from, test.to, test.latest)
+		if test.expectedErr != "" {
+			assert.EqualError(err, test.expectedErr)
+			continue
+		}
+		assert.NoError(err)
+		assert.Equal(test.expected, ts.Data)
+	}
+}
+
+func TestJHUMultipleProvinces(t *testing.T) {
+	assert := assert.New(t)
+	source := NewJHUSource("testdata/jhu")
+
+	series, err := source.ProvinceSeries("australia", []string{"victoria", "tasmania", "new south wales"}, time.Time{}, time.Time{}, true)
+	assert.EqualError(err, "tasmania: country not found: province australia/tasmania")
+	assert.Len(series, 2)
+	assert.Equal("Victoria", series[0].Data[0].Province)
+	assert.Equal("New South Wales", series[1].Data[0].Province)
+
+	provinces, err := source.Provinces("australia")
+	assert.NoError(err)
+	assert.Equal(Provinces{"Australian Capital Territory", "New South Wales", "Victoria"}, provinces)
+
+	provinces, err = source.Provinces("new zealand")
+	assert.NoError(err)
+	assert.Empty(provinces)
+}
+
+func TestJHUSeries(t *testing.T) {
+	assert := assert.New(t)
+
+	ts, err := NewJHUSource("testdata/jhu").Series("australia", time.Time{}, time.Time{}, true)
+	assert.NoError(err)
+	assert.Equal([]Day{{Country: "Australia", Date: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC), Cases: 25718, Deaths: 877, Recovered: 22791}}, ts.Data)
+
+	_, err = NewJHUSource("testdata/missing").Series("australia", time.Time{}, time.Time{}, true)
+	assert.Error(err)
+}
+
+func TestJHUColumns(t *testing.T) {
+	assert := assert.New(t)
+	provinceCol, countryCol, dateCols := jhuColumns([]string{"\ufeffProvince/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20"})
+	assert.Equal(0, provinceCol)
+	assert.Equal(1, countryCol)
+	assert.Equal([]int{4, 5}, dateCols)
+}
@@ -0,0 +1,257 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//OWIDSource reads the Our World in Data owid-covid-data csv or json file from a local path or url
+type OWIDSource struct {
+	Client *http.Client
+	Path   string
+}
+
+//owidDay the columns of a single OWID observation which fit into a Day
+type owidDay struct {
+	Date              string  `json:"date"`
+	TotalCases        float64 `json:"total_cases"`
+	TotalDeaths       float64 `json:"total_deaths"`
+	TotalTests        float64 `json:"total_tests"`
+	HospPatients      float64 `json:"hosp_patients"`
+	TotalVaccinations float64 `json:"total_vaccinations"`
+}
+
+//owidLocation a single country in the OWID json file
+type owidLocation struct {
+	Location string    `json:"location"`
+	Data     []owidDay `json:"data"`
+}
+
+//NewOWIDSource returns a data source for the OWID file at path, which may also be a http(s) url
+func NewOWIDSource(path string) *OWIDSource {
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			IdleConnTimeout: 10 * time.Second,
+		},
+	}
+	return &OWIDSource{Client: httpClient, Path: path}
+}
+
+//Series returns the time series for a country, matched on either the OWID location or iso code. The reserved global names return the World totals
+func (s *OWIDSource) Series(country string, from, to time.Time, latest bool) (TimeSeries, error) {
+	return s.SeriesContext(context.Background(), country, from, to, latest)
+}
+
+//SeriesContext returns the time series for a country like Series, giving up on a download when ctx is cancelled
+func (s *OWIDSource) SeriesContext(ctx context.Context, country string, from, to time.Time, latest bool) (TimeSeries, error) {
+	series, err := s.SeriesBatchContext(ctx, []string{country}, from, to, latest)
+	if failed, ok := err.(SeriesError); ok {
+		err = failed[country]
+	}
+	if len(series) == 0 {
+		return TimeSeries{}, err
+	}
+	return series[0], err
+}
+
+//SeriesBatch returns the time series for several countries from a single read of the file, so a url is only
+//downloaded once. The series are in the order of countries, any country which isn't in the file is left out and
+//reported in a SeriesError
+func (s *OWIDSource) SeriesBatch(countries []string, from, to time.Time, latest bool) ([]TimeSeries, error) {
+	return s.SeriesBatchContext(context.Background(), countries, from, to, latest)
+}
+
+//SeriesBatchContext returns the time series for several countries like SeriesBatch, giving up on the download when
+//ctx is cancelled
+func (s *OWIDSource) SeriesBatchContext(ctx context.Context, countries []string, from, to time.Time, latest bool) ([]TimeSeries, error) {
+	// the names to look for, where the reserved global names are the World totals
+	names := make([]string, len(countries))
+	for i, country := range countries {
+		names[i] = country
+		if IsGlobal(country) {
+			names[i] = "OWID_WRL"
+		}
+	}
+	r, err := s.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var locations []owidLocation
+	if strings.HasSuffix(strings.ToLower(s.Path), ".json") {
+		locations, err = readOWIDJSON(r, names)
+	} else {
+		locations, err = readOWIDCSV(r, names)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var series []TimeSeries
+	failed := SeriesError{}
+	for i, country := range countries {
+		if len(locations[i].Data) == 0 {
+			failed[country] = fmt.Errorf("%w: %v", ErrCountryNotFound, names[i])
+			continue
+		}
+		ts, err := locations[i].series(from, to, latest)
+		if err != nil {
+			failed[country] = err
+			continue
+		}
+		series = append(series, ts)
+	}
+	if len(failed) > 0 {
+		return series, failed
+	}
+	return series, nil
+}
+
+//series the days of the location between from and to
+func (l owidLocation) series(from, to time.Time, latest bool) (TimeSeries, error) {
+	var ts TimeSeries
+	for _, obs := range l.Data {
+		date, err := time.Parse("2006-01-02", obs.Date)
+		if err != nil {
+			return ts, err
+		}
+		ts.Data = append(ts.Data, Day{
+			Country:      l.Location,
+			Date:         date,
+			Cases:        int(obs.TotalCases),
+			Deaths:       int(obs.TotalDeaths),
+			Tests:        int(obs.TotalTests),
+			Hospitalised: int(obs.HospPatients),
+			Doses:        int(obs.TotalVaccinations),
+		})
+	}
+	ts.Order()
+	ts.Filter(from, to, latest)
+	return ts, nil
+}
+
+//open the OWID file, which is downloaded with a request cancelled along with ctx when the path is a url
+func (s *OWIDSource) open(ctx context.Context) (io.ReadCloser, error) {
+	if !strings.HasPrefix(s.Path, "http://") && !strings.HasPrefix(s.Path, "https://") {
+		return os.Open(s.Path)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.Path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		resp.Body.Close()
+		return nil, &UpstreamError{StatusCode: resp.StatusCode, URL: s.Path, Message: fmt.Sprintf("unable to download %v: %v", s.Path, resp.Status)}
+	}
+	return resp.Body, nil
+}
+
+//readOWIDJSON the location of each of the names, matched on either the OWID location or iso code. A name which
+//isn't in the file has an empty location
+func readOWIDJSON(r io.Reader, names []string) ([]owidLocation, error) {
+	var data map[string]owidLocation
+	err := json.NewDecoder(r).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	locations := make([]owidLocation, len(names))
+	for isoCode, location := range data {
+		for i, name := range names {
+			if strings.EqualFold(isoCode, name) || strings.EqualFold(location.Location, name) {
+				locations[i] = location
+			}
+		}
+	}
+	return locations, nil
+}
+
+//readOWIDCSV the location of each of the names like readOWIDJSON, from the rows of the csv file
+func readOWIDCSV(r io.Reader, names []string) ([]owidLocation, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	cols := map[string]int{}
+	for i, col := range header {
+		cols[strings.TrimPrefix(col, "\ufeff")] = i
+	}
+	isoCol, okISO := cols["iso_code"]
+	locationCol, okLocation := cols["location"]
+	dateCol, okDate := cols["date"]
+	if !okISO || !okLocation || !okDate {
+		return nil, ErrorBadCSVHeader
+	}
+
+	locations := make([]owidLocation, len(names))
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var matches []int
+		for i, name := range names {
+			if strings.EqualFold(record[isoCol], name) || strings.EqualFold(record[locationCol], name) {
+				matches = append(matches, i)
+			}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		day := owidDay{Date: record[dateCol]}
+		for col, value := range map[string]*float64{
+			"total_cases":        &day.TotalCases,
+			"total_deaths":       &day.TotalDeaths,
+			"total_tests":        &day.TotalTests,
+			"hosp_patients":      &day.HospPatients,
+			"total_vaccinations": &day.TotalVaccinations,
+		} {
+			i, ok := cols[col]
+			if !ok || record[i] == "" {
+				continue
+			}
+			*value, err = strconv.ParseFloat(record[i], 64)
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, i := range matches {
+			locations[i].Location = record[locationCol]
+			locations[i].Data = append(locations[i].Data, day)
+		}
+	}
+	return locations, nil
+}
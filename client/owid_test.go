@@ -0,0 +1,129 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOWIDSeries(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata/owid")))
+	defer server.Close()
+
+	tests := []struct {
+		path        string
+		country     string
+		latest      bool
+		expected    []Day
+		expectedErr bool
+	}{
+		{
+			path:    "testdata/owid/owid-covid-data.csv",
+			country: "australia",
+			expected: []Day{
+				{Country: "Australia", Date: time.Date(2021, 3, 24, 0, 0, 0, 0, time.UTC), Cases: 29230, Deaths: 909, Tests: 14936571, Doses: 158000},
+				{Country: "Australia", Date: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC), Cases: 29239, Deaths: 909, Tests: 14985005, Doses: 196000},
+			},
+		},
+		{
+			path:    "testdata/owid/owid-covid-data.csv",
+			country: "NZL",
+			latest:  true,
+			expected: []Day{
+				{Country: "New Zealand", Date: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC), Cases: 2482, Deaths: 26, Tests: 1810000, Hospitalised: 1},
+			},
+		},
+		{
+			path:    "testdata/owid/owid-covid-data.json",
+			country: "aus",
+			expected: []Day{
+				{Country: "Australia", Date: time.Date(2021, 3, 24, 0, 0, 0, 0, time.UTC), Cases: 29230, Deaths: 909, Tests: 14936571, Doses: 158000},
+				{Country: "Australia", Date: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC), Cases: 29239, Deaths: 909, Tests: 14985005, Doses: 196000},
+			},
+		},
+		{
+			path:    server.URL + "/owid-covid-data.json",
+			country: "new zealand",
+			latest:  true,
+			expected: []Day{
+				{Country: "New Zealand", Date: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC), Cases: 2482, Deaths: 26, Hospitalised: 1},
+			},
+		},
+		{
+			path:    "testdata/owid/owid-covid-data.csv",
+			country: "all",
+			latest:  true,
+			expected: []Day{
+				{Country: "World", Date: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC), Cases: 125000000, Deaths: 2750000, Doses: 500000000},
+			},
+		},
+		{path: "testdata/owid/owid-covid-data.csv", country: "azzz", expectedErr: true},
+		{path: server.URL + "/missing.csv", country: "australia", expectedErr: true},
+		{path: "testdata/owid/missing.csv", country: "australia", expectedErr: true},
+	}
+
+	for _, test := range tests {
+		ts, err := NewOWIDSource(test.path).Series(test.country, time.Date(2021, 3, 24, 0, 0, 0, 0, time.UTC), time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC), test.latest)
+		if test.expectedErr {
+			assert.Error(err)
+			continue
+		}
+		assert.NoError(err)
+		assert.Equal(test.expected, ts.Data)
+	}
+}
+
+func TestOWIDSeriesBatch(t *testing.T) {
+	assert := assert.New(t)
+
+	downloads := 0
+	files := http.FileServer(http.Dir("testdata/owid"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		files.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	day := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{path: server.URL + "/owid-covid-data.csv", expected: []string{"New Zealand", "World", "Australia"}},
+		// the json test file doesn't have the world
+		{path: server.URL + "/owid-covid-data.json", expected: []string{"New Zealand", "Australia"}},
+	}
+	for _, test := range tests {
+		downloads = 0
+		series, err := FetchAll(NewOWIDSource(test.path), []string{"nzl", "global", "azzz", "australia"}, day, day, false, 4)
+		assert.Error(err)
+		assert.Contains(err.Error(), "azzz: country not found: azzz")
+		assert.Equal(1, downloads, test.path)
+		var countries []string
+		for _, ts := range series {
+			countries = append(countries, ts.Data[0].Country)
+		}
+		assert.Equal(test.expected, countries, test.path)
+	}
+}
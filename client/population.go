@@ -0,0 +1,177 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	_ "embed" // the population table
+	"encoding/csv"
+	"strconv"
+	"strings"
+)
+
+//go:embed data/population.csv
+var populationTable string
+
+//PopulationVersion the edition of the embedded population table. Update it along with the table
+const PopulationVersion = "UN World Population Prospects 2019, 1 July 2020"
+
+//notificationDays the days the notification rate is over
+const notificationDays = 14
+
+//worldNames the names the sources give the whole world, besides the reserved global names
+var worldNames = []string{"World", "OWID_WRL"}
+
+//isWorld whether country is a name for the whole world
+func isWorld(country string) bool {
+	for _, name := range worldNames {
+		if strings.EqualFold(strings.TrimSpace(country), name) {
+			return true
+		}
+	}
+	return IsGlobal(country)
+}
+
+//PopulationSource the optional interface of a data source which knows the population of its countries
+type PopulationSource interface {
+	PopulationsContext(ctx context.Context, countries []string) (map[string]int, error)
+}
+
+//EmbeddedPopulations the population of each country which is in the embedded table, by the name given. The
+//reserved global names and the continents of the country table are the sum of their countries
+func EmbeddedPopulations(countries []string) (map[string]int, error) {
+	reader := csv.NewReader(strings.NewReader(populationTable))
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	byCode := map[string]int{}
+	for _, record := range records[1:] {
+		population, err := strconv.Atoi(record[1])
+		if err != nil {
+			return nil, err
+		}
+		byCode[record[0]] = population
+	}
+	resolver, err := NewResolver()
+	if err != nil {
+		return nil, err
+	}
+	populations := map[string]int{}
+	for _, country := range countries {
+		if c, ok := resolver.Lookup(country); ok && byCode[c.ISO3] > 0 {
+			populations[country] = byCode[c.ISO3]
+			continue
+		}
+		// the world and the continents are the sum of their countries
+		for _, c := range resolver.Countries() {
+			if isWorld(country) || strings.EqualFold(c.Continent, country) {
+				populations[country] += byCode[c.ISO3]
+			}
+		}
+		if populations[country] == 0 {
+			delete(populations, country)
+		}
+	}
+	return populations, nil
+}
+
+//Populations the population of each country from the server, which are more recent than the embedded table.
+//Any country the server doesn't have, or all of them if the server can't be reached, is looked up in the
+//embedded table
+func (c *APIClient) Populations(countries []string) (map[string]int, error) {
+	return c.PopulationsContext(context.Background(), countries)
+}
+
+//PopulationsContext the population of each country like Populations. Once ctx is cancelled ctx.Err() is
+//returned rather than falling back to the embedded table
+func (c *APIClient) PopulationsContext(ctx context.Context, countries []string) (map[string]int, error) {
+	populations, err := EmbeddedPopulations(countries)
+	if err != nil {
+		return nil, err
+	}
+	// the countries the server does have are still returned along with a SeriesError
+	snapshots, _ := c.SnapshotsContext(ctx, countries)
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, country := range countries {
+		i := snapshots.find(country)
+		if isWorld(country) {
+			i = snapshots.find("Global")
+		}
+		if i >= 0 && snapshots[i].Population > 0 {
+			populations[country] = snapshots[i].Population
+		}
+	}
+	return populations, nil
+}
+
+//PerLabel the short name of per people, e.g. 100k
+func PerLabel(per int) string {
+	if per >= 1000000 && per%1000000 == 0 {
+		return strconv.Itoa(per/1000000) + "m"
+	}
+	if per >= 1000 && per%1000 == 0 {
+		return strconv.Itoa(per/1000) + "k"
+	}
+	return strconv.Itoa(per)
+}
+
+//PerCapita sets the cases and deaths per people (e.g. 100000) on each day, from the population of its country.
+//Provinces and counties aren't the whole population of the country, so are left without
+func (ts *TimeSeries) PerCapita(populations map[string]int, per int) {
+	for i, obs := range ts.Data {
+		population := populations[obs.Country]
+		if population == 0 || obs.Province != "" || obs.County != "" {
+			continue
+		}
+		scale := float64(per) / float64(population)
+		ts.Data[i].Per = per
+		ts.Data[i].CasesPer = float64(obs.Cases) * scale
+		ts.Data[i].DeathsPer = float64(obs.Deaths) * scale
+	}
+}
+
+//NotificationRate sets the new cases of the last 14 days per 100,000 people on each day which has the day 14 days
+//before it (by date, like Rolling). It is the change of the cumulative cases, so NotificationRate goes before Daily
+func (ts *TimeSeries) NotificationRate(populations map[string]int) {
+	type key struct {
+		country string
+		date    int64
+	}
+	index := map[key]int{}
+	for i, obs := range ts.Data {
+		if obs.Province != "" || obs.County != "" {
+			continue
+		}
+		index[key{obs.Country, obs.Date.Unix()}] = i
+	}
+	for i, obs := range ts.Data {
+		population := populations[obs.Country]
+		if population == 0 || obs.Province != "" || obs.County != "" {
+			continue
+		}
+		first, ok := index[key{obs.Country, obs.Date.AddDate(0, 0, -notificationDays).Unix()}]
+		if !ok {
+			continue
+		}
+		ts.Data[i].RateDays = notificationDays
+		ts.Data[i].NotificationRate = float64(obs.Cases-ts.Data[first].Cases) * 100000 / float64(population)
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbeddedPopulations(t *testing.T) {
+	assert := assert.New(t)
+
+	populations, err := EmbeddedPopulations([]string{"Australia", "nzl", "Korea, South", "United States", "Diamond Princess"})
+	assert.NoError(err)
+	assert.Equal(map[string]int{
+		"Australia":     25499884,
+		"nzl":           4822233,
+		"Korea, South":  51269185,
+		"United States": 331002651,
+	}, populations)
+
+	// the world and continents add up their countries
+	populations, err = EmbeddedPopulations([]string{"Global", "World", "OWID_WRL", "Australia-Oceania", "europe", "Atlantis"})
+	assert.NoError(err)
+	assert.True(populations["Global"] > 7000000000)
+	assert.Equal(populations["Global"], populations["World"])
+	assert.Equal(populations["Global"], populations["OWID_WRL"])
+	assert.True(populations["Australia-Oceania"] > 25499884+4822233)
+	assert.True(populations["Australia-Oceania"] < 50000000)
+	assert.True(populations["europe"] > 700000000)
+	assert.NotContains(populations, "Atlantis")
+
+	// every country in the table has a population
+	resolver, err := NewResolver()
+	assert.NoError(err)
+	var names []string
+	for _, c := range resolver.Countries() {
+		names = append(names, c.Name)
+	}
+	populations, err = EmbeddedPopulations(names)
+	assert.NoError(err)
+	assert.Len(populations, len(names))
+}
+
+func TestPopulations(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/countries/Australia,New Zealand":
+			w.Write([]byte("[" + snapshotData + "]"))
+		case "/all":
+			w.Write([]byte(globalSnapshotData))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL + "/historical/%v?lastdays=%v")
+	client.Retry.Base = time.Millisecond
+
+	populations, err := client.Populations([]string{"Australia", "New Zealand", "global"})
+	assert.NoError(err)
+	assert.Equal(map[string]int{"Australia": 25788217, "New Zealand": 4822233, "global": 7794798729}, populations)
+
+	// the embedded table when the server doesn't know the country
+	populations, err = client.Populations([]string{"Germany"})
+	assert.NoError(err)
+	assert.Equal(map[string]int{"Germany": 83783942}, populations)
+}
+
+func TestPerLabel(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("100k", PerLabel(100000))
+	assert.Equal("1m", PerLabel(1000000))
+	assert.Equal("250", PerLabel(250))
+}
+
+func TestPerCapita(t *testing.T) {
+	assert := assert.New(t)
+
+	date := func(day int) time.Time {
+		return time.Date(2021, 3, day, 0, 0, 0, 0, time.UTC)
+	}
+	var ts TimeSeries
+	for day := 1; day <= 16; day++ {
+		ts.Data = append(ts.Data, Day{Country: "Australia", Date: date(day), Cases: 1000 + day*50, Deaths: 10})
+	}
+	ts.Data = append(ts.Data, Day{Country: "Australia", Province: "Victoria", Date: date(16), Cases: 500})
+	ts.Data = append(ts.Data, Day{Country: "Atlantis", Date: date(16), Cases: 1})
+	populations := map[string]int{"Australia": 2000000}
+
+	ts.NotificationRate(populations)
+	ts.PerCapita(populations, 100000)
+	assert.Equal(0, ts.Data[13].RateDays)
+	assert.Equal(14, ts.Data[14].RateDays)
+	assert.Equal(35.0, ts.Data[14].NotificationRate)
+	assert.Equal(90.0, ts.Data[15].CasesPer)
+	assert.Equal(0.5, ts.Data[15].DeathsPer)
+	assert.Equal(0, ts.Data[16].Per)
+	assert.Equal(0, ts.Data[17].Per)
+
+	ts.Filter(date(15), date(16), false)
+	buf := new(bytes.Buffer)
+	ts.Print(buf, "csv")
+	assert.Equal("Country,Province,Date,Cases,Deaths,Recovered,Cases per 100k,Deaths per 100k,14d Rate per 100k\n"+
+		"Australia,,2021-03-15,1750,10,0,87.50,0.50,35.0\n"+
+		"Australia,,2021-03-16,1800,10,0,90.00,0.50,35.0\n"+
+		"Australia,Victoria,2021-03-16,500,0,0,,,\n"+
+		"Atlantis,,2021-03-16,1,0,0,,,\n", buf.String())
+
+	// the 14 days are by date, so a missing day leaves out the rates which would start on it
+	var gaps TimeSeries
+	for day := 1; day <= 17; day++ {
+		if day != 2 {
+			gaps.Data = append(gaps.Data, Day{Country: "Australia", Date: date(day), Cases: day * 100})
+		}
+	}
+	gaps.NotificationRate(map[string]int{"Australia": 100000})
+	var rates []float64
+	for _, obs := range gaps.Data[12:] {
+		rates = append(rates, obs.NotificationRate)
+	}
+	assert.Equal([]float64{0, 1400, 0, 1400}, rates)
+}
@@ -0,0 +1,210 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	_ "embed" // the country table
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed data/countries.csv
+var countryTable string
+
+//Country a country from the embedded country table
+type Country struct {
+	Name      string // The name disease.sh uses
+	ISO2      string
+	ISO3      string
+	Continent string
+	Aliases   []string
+}
+
+//UnknownCountryError a name which isn't in the country table, with the closest countries (if any are close).
+//It matches ErrCountryNotFound with errors.Is
+type UnknownCountryError struct {
+	Name        string
+	Suggestions []string
+}
+
+func (e *UnknownCountryError) Error() string {
+	switch len(e.Suggestions) {
+	case 0:
+		return fmt.Sprintf("unknown country %q", e.Name)
+	case 1:
+		return fmt.Sprintf("unknown country %q, did you mean %v?", e.Name, e.Suggestions[0])
+	default:
+		last := len(e.Suggestions) - 1
+		return fmt.Sprintf("unknown country %q, did you mean %v or %v?", e.Name, strings.Join(e.Suggestions[:last], ", "), e.Suggestions[last])
+	}
+}
+
+func (e *UnknownCountryError) Is(target error) bool {
+	return target == ErrCountryNotFound
+}
+
+//Resolver finds countries by name, ISO code or alias, and corrects small misspellings
+type Resolver struct {
+	countries []Country
+	index     map[string]int // normalised names, aliases and codes to countries
+}
+
+//maxSuggestions the most countries suggested for an unknown name
+const maxSuggestions = 3
+
+//the resolver for the embedded table, which is parsed by the first NewResolver
+var (
+	resolverOnce    sync.Once
+	defaultResolver *Resolver
+	resolverErr     error
+)
+
+//NewResolver returns the resolver for the embedded country table. The table is only parsed once, and the
+//resolver is shared as it is never changed
+func NewResolver() (*Resolver, error) {
+	resolverOnce.Do(func() {
+		defaultResolver, resolverErr = parseCountryTable(countryTable)
+	})
+	return defaultResolver, resolverErr
+}
+
+//parseCountryTable builds a resolver from the csv country table
+func parseCountryTable(table string) (*Resolver, error) {
+	records, err := csv.NewReader(strings.NewReader(table)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	r := &Resolver{index: map[string]int{}}
+	for _, record := range records[1:] {
+		country := Country{Name: record[0], ISO2: record[1], ISO3: record[2], Continent: record[3]}
+		if record[4] != "" {
+			country.Aliases = strings.Split(record[4], ";")
+		}
+		r.countries = append(r.countries, country)
+		for _, key := range append([]string{country.Name, country.ISO2, country.ISO3}, country.Aliases...) {
+			r.index[normaliseCountry(key)] = len(r.countries) - 1
+		}
+	}
+	return r, nil
+}
+
+//Countries every country in the table, in the order disease.sh lists them
+func (r *Resolver) Countries() []Country {
+	return append([]Country(nil), r.countries...)
+}
+
+//Lookup finds a country by its exact name, ISO code or alias, ignoring case and punctuation
+func (r *Resolver) Lookup(name string) (Country, bool) {
+	i, ok := r.index[normaliseCountry(name)]
+	if !ok {
+		return Country{}, false
+	}
+	return r.countries[i], true
+}
+
+//Resolve finds a country like Lookup, or the country closest to a misspelt name when only one country is close.
+//Otherwise an UnknownCountryError suggests the closest countries
+func (r *Resolver) Resolve(name string) (Country, error) {
+	if country, ok := r.Lookup(name); ok {
+		return country, nil
+	}
+	n := normaliseCountry(name)
+	type candidate struct {
+		country  int
+		distance int
+	}
+	closest := map[int]int{}
+	for key, i := range r.index {
+		// codes are too short to be misspelt
+		if len(key) <= 3 {
+			continue
+		}
+		d := levenshtein(n, key)
+		if best, ok := closest[i]; !ok || d < best {
+			closest[i] = d
+		}
+	}
+	var candidates []candidate
+	for i, d := range closest {
+		candidates = append(candidates, candidate{i, d})
+	}
+	sort.Slice(candidates, func(a, b int) bool {
+		if candidates[a].distance != candidates[b].distance {
+			return candidates[a].distance < candidates[b].distance
+		}
+		return r.countries[candidates[a].country].Name < r.countries[candidates[b].country].Name
+	})
+
+	// a quarter of the name may be wrong before it is only a suggestion, and a third before it isn't close at all
+	length := len([]rune(n))
+	correct, suggest := length/4, length/3
+	if correct < 1 {
+		correct = 1
+	}
+	if suggest < 2 {
+		suggest = 2
+	}
+	if len(candidates) > 0 && length >= 4 && candidates[0].distance <= correct &&
+		(len(candidates) == 1 || candidates[1].distance > candidates[0].distance) {
+		return r.countries[candidates[0].country], nil
+	}
+	unknown := &UnknownCountryError{Name: name}
+	for _, c := range candidates {
+		if c.distance > suggest || len(unknown.Suggestions) == maxSuggestions {
+			break
+		}
+		unknown.Suggestions = append(unknown.Suggestions, r.countries[c.country].Name)
+	}
+	return Country{}, unknown
+}
+
+//normaliseCountry lower case without dots or repeated spaces, so "S. Korea" and "s korea" are the same
+func normaliseCountry(name string) string {
+	name = strings.ToLower(strings.ReplaceAll(name, ".", ""))
+	return strings.Join(strings.Fields(name), " ")
+}
+
+//levenshtein the number of single character edits to turn a into b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,111 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	assert := assert.New(t)
+	resolver, err := NewResolver()
+	assert.NoError(err)
+
+	tests := []struct {
+		in          string
+		expected    string
+		suggestions []string
+	}{
+		{in: "australia", expected: "Australia"},
+		{in: "AU", expected: "Australia"},
+		{in: "aus", expected: "Australia"},
+		{in: "USA", expected: "USA"},
+		{in: "united states", expected: "USA"},
+		{in: "us", expected: "USA"},
+		{in: "UK", expected: "UK"},
+		{in: "United Kingdom", expected: "UK"},
+		{in: "Czech Republic", expected: "Czechia"},
+		{in: "czechia", expected: "Czechia"},
+		{in: "s korea", expected: "S. Korea"},
+		{in: "  south   korea ", expected: "S. Korea"},
+		{in: "ivory coast", expected: "Côte d'Ivoire"},
+		{in: "austrailia", expected: "Australia"},
+		{in: "germny", expected: "Germany"},
+		{in: "nigerr", expected: "Niger"},
+		{in: "nigeri", suggestions: []string{"Niger", "Nigeria"}},
+		{in: "chda", suggestions: []string{"Chad", "China", "Cuba"}},
+		{in: "azzz"},
+		{in: "diamond princess"},
+	}
+	for _, test := range tests {
+		country, err := resolver.Resolve(test.in)
+		if test.expected != "" {
+			assert.NoError(err, test.in)
+			assert.Equal(test.expected, country.Name, test.in)
+			continue
+		}
+		var unknown *UnknownCountryError
+		assert.True(errors.As(err, &unknown), test.in)
+		assert.True(errors.Is(err, ErrCountryNotFound))
+		assert.Equal(test.suggestions, unknown.Suggestions, test.in)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	assert := assert.New(t)
+	resolver, err := NewResolver()
+	assert.NoError(err)
+
+	country, ok := resolver.Lookup("new zealand")
+	assert.True(ok)
+	assert.Equal(Country{Name: "New Zealand", ISO2: "NZ", ISO3: "NZL", Continent: "Australia-Oceania"}, country)
+
+	_, ok = resolver.Lookup("austrailia")
+	assert.False(ok)
+
+	// the table is only parsed once
+	again, err := NewResolver()
+	assert.NoError(err)
+	assert.True(resolver == again)
+
+	countries := resolver.Countries()
+	assert.True(len(countries) > 200)
+	for _, country := range countries {
+		assert.Len(country.ISO2, 2, country.Name)
+		assert.Len(country.ISO3, 3, country.Name)
+		assert.NotEmpty(country.Continent, country.Name)
+	}
+}
+
+func TestUnknownCountryError(t *testing.T) {
+	assert := assert.New(t)
+	assert.EqualError(&UnknownCountryError{Name: "azzz"}, `unknown country "azzz"`)
+	assert.EqualError(&UnknownCountryError{Name: "chda", Suggestions: []string{"Chad"}}, `unknown country "chda", did you mean Chad?`)
+	assert.EqualError(&UnknownCountryError{Name: "x", Suggestions: []string{"A", "B", "C"}}, `unknown country "x", did you mean A, B or C?`)
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(0, levenshtein("chad", "chad"))
+	assert.Equal(1, levenshtein("austrailia", "australia"))
+	assert.Equal(2, levenshtein("chda", "chad"))
+	assert.Equal(3, levenshtein("", "abc"))
+	assert.Equal(1, levenshtein("curaçao", "curacao"))
+}
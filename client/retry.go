@@ -0,0 +1,134 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+//DefaultRetries how many times a failed request is tried again
+const DefaultRetries = 3
+
+//Retry an http.RoundTripper which tries a GET request again after a 429, a 5xx, a timeout or a dropped or
+//refused connection. Other errors, like a bad certificate or url, fail straight away. The wait
+//doubles for each retry (with some jitter so several clients don't come back at once), unless the server
+//asks for a time with Retry-After
+type Retry struct {
+	Transport http.RoundTripper
+	Retries   int           // How many times to try again after the first request
+	Base      time.Duration // The wait before the first retry
+	Max       time.Duration // The longest wait, a longer Retry-After returns the response instead
+}
+
+//NewRetry returns a Retry which sends the requests through transport
+func NewRetry(transport http.RoundTripper, retries int) *Retry {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Retry{Transport: transport, Retries: retries, Base: 500 * time.Millisecond, Max: 30 * time.Second}
+}
+
+//RoundTrip sends the request, trying again while the failure looks temporary
+func (r *Retry) RoundTrip(req *http.Request) (*http.Response, error) {
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+	for attempt := 0; ; attempt++ {
+		resp, err := r.Transport.RoundTrip(req)
+		if !idempotent || attempt >= r.Retries || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		wait := r.backoff(attempt)
+		if err != nil && !retryable(err) {
+			return resp, err
+		}
+		if err == nil {
+			if !temporary(resp.StatusCode) {
+				return resp, nil
+			}
+			if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				if after > r.Max {
+					return resp, nil
+				}
+				wait = after
+			}
+			// the connection can only be reused once the body is read
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+//retryable whether a transport error might not happen again: a timeout or a connection which was reset,
+//refused or closed early
+func retryable(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+//backoff the wait before retry attempt+1, somewhere between half and all of Base doubled attempt times
+func (r *Retry) backoff(attempt int) time.Duration {
+	wait := r.Base << uint(attempt)
+	if wait > r.Max || wait <= 0 {
+		wait = r.Max
+	}
+	if wait < 2 {
+		return wait
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)))
+}
+
+//retryAfter parses a Retry-After header, which is either a number of seconds or a date
+func retryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(header)
+	if err != nil {
+		return 0, false
+	}
+	wait := time.Until(date)
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, true
+}
@@ -0,0 +1,182 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRetry(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		failures   int
+		status     int
+		retryAfter string
+		retries    int
+		requests   int
+		expectErr  bool
+	}{
+		{failures: 2, status: http.StatusBadGateway, retries: 3, requests: 3},
+		{failures: 1, status: http.StatusTooManyRequests, retryAfter: "0", retries: 3, requests: 2},
+		{failures: 5, status: http.StatusServiceUnavailable, retries: 2, requests: 3, expectErr: true},
+		{failures: 1, status: http.StatusBadGateway, retries: 0, requests: 1, expectErr: true},
+		// a wait longer than Max gives up straight away
+		{failures: 1, status: http.StatusTooManyRequests, retryAfter: "3600", retries: 3, requests: 1, expectErr: true},
+		// a client error won't get better by asking again
+		{failures: 1, status: http.StatusNotFound, retries: 3, requests: 1, expectErr: true},
+	}
+
+	for _, test := range tests {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests <= test.failures {
+				if test.retryAfter != "" {
+					w.Header().Set("Retry-After", test.retryAfter)
+				}
+				w.WriteHeader(test.status)
+				w.Write([]byte(`{"message":"try again"}`))
+				return
+			}
+			w.Write([]byte(responseData))
+		}))
+
+		c := NewClient(server.URL + "/historical/%v?lastdays=%v")
+		c.Retry.Retries = test.retries
+		c.Retry.Base = time.Millisecond
+		_, err := c.Get("australia", time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC), time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC), false)
+		if test.expectErr {
+			assert.Error(err)
+		} else {
+			assert.NoError(err)
+		}
+		assert.Equal(test.requests, requests)
+		server.Close()
+	}
+}
+
+func TestRetryNetworkError(t *testing.T) {
+	assert := assert.New(t)
+	attempts := 0
+	retry := NewRetry(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}), 3)
+	retry.Base = time.Millisecond
+
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/historical/australia", nil)
+	resp, err := retry.RoundTrip(req)
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal(3, attempts)
+
+	// an error which will happen every time isn't retried
+	for _, failure := range []error{
+		x509.UnknownAuthorityError{},
+		&url.Error{Op: "parse", URL: "http://[::1", Err: errors.New("missing ']' in host")},
+		errors.New("unsupported protocol scheme"),
+	} {
+		attempts = 0
+		retry.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			return nil, failure
+		})
+		_, err = retry.RoundTrip(req)
+		assert.Error(err)
+		assert.Equal(1, attempts)
+	}
+
+	// as are timeouts and refused connections
+	for _, failure := range []error{
+		&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+		&url.Error{Op: "Get", URL: "http://localhost", Err: timeoutError{}},
+	} {
+		attempts = 0
+		retry.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			return nil, failure
+		})
+		_, err = retry.RoundTrip(req)
+		assert.Error(err)
+		assert.Equal(4, attempts)
+	}
+
+	// other methods aren't safe to send twice
+	attempts = 0
+	req, _ = http.NewRequest(http.MethodPost, "http://localhost/alert", nil)
+	_, err = retry.RoundTrip(req)
+	assert.Error(err)
+	assert.Equal(1, attempts)
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestRetryAfter(t *testing.T) {
+	assert := assert.New(t)
+
+	wait, ok := retryAfter("120")
+	assert.True(ok)
+	assert.Equal(2*time.Minute, wait)
+
+	wait, ok = retryAfter(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+	assert.True(ok)
+	assert.Equal(time.Duration(0), wait)
+
+	wait, ok = retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	assert.True(ok)
+	assert.True(wait > 59*time.Minute)
+
+	for _, header := range []string{"", "soon", "-1"} {
+		_, ok = retryAfter(header)
+		assert.False(ok)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	assert := assert.New(t)
+	retry := NewRetry(nil, 3)
+	for attempt, max := range []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second} {
+		wait := retry.backoff(attempt)
+		assert.True(wait >= max/2 && wait <= max)
+	}
+	assert.True(retry.backoff(100) <= retry.Max)
+}
@@ -0,0 +1,167 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	snapshotHeader = []string{"Country", "Updated", "Cases", "Today Cases", "Deaths", "Today Deaths", "Active", "Critical", "Tests", "Population", "Cases Per Million", "Deaths Per Million", "Tests Per Million"}
+)
+
+//CountryInfo the codes of a country in the snapshot response
+type CountryInfo struct {
+	ISO2 string `json:"iso2"`
+	ISO3 string `json:"iso3"`
+}
+
+//Snapshot the current numbers for a country from the server
+type Snapshot struct {
+	Updated             int64       `json:"updated"` // Milliseconds since the unix epoch
+	Country             string      `json:"country"`
+	CountryInfo         CountryInfo `json:"countryInfo"`
+	Continent           string      `json:"continent"`
+	Cases               int         `json:"cases"`
+	TodayCases          int         `json:"todayCases"`
+	Deaths              int         `json:"deaths"`
+	TodayDeaths         int         `json:"todayDeaths"`
+	Recovered           int         `json:"recovered"`
+	Active              int         `json:"active"`
+	Critical            int         `json:"critical"`
+	Tests               int         `json:"tests"`
+	Population          int         `json:"population"`
+	CasesPerOneMillion  float64     `json:"casesPerOneMillion"`
+	DeathsPerOneMillion float64     `json:"deathsPerOneMillion"`
+	TestsPerOneMillion  float64     `json:"testsPerOneMillion"`
+}
+
+//Snapshots the current numbers for several countries
+type Snapshots []Snapshot
+
+//UpdatedTime the time the server last updated the snapshot
+func (s Snapshot) UpdatedTime() time.Time {
+	return time.Unix(0, s.Updated*int64(time.Millisecond)).UTC()
+}
+
+//Snapshots queries the server for the current numbers of one or more countries with a single request.
+//The reserved global names return the numbers for the whole world. Any country the server doesn't
+//return is left out and reported in a SeriesError
+func (c *APIClient) Snapshots(countries []string) (Snapshots, error) {
+	return c.SnapshotsContext(context.Background(), countries)
+}
+
+//SnapshotsContext queries the server for the current numbers like Snapshots, giving up when ctx is cancelled
+func (c *APIClient) SnapshotsContext(ctx context.Context, countries []string) (Snapshots, error) {
+	var data Snapshots
+	failed := SeriesError{}
+	var names []string
+	for _, country := range countries {
+		if !IsGlobal(country) {
+			names = append(names, country)
+			continue
+		}
+		var global Snapshot
+		err := c.getJSONContext(ctx, c.BaseURL+"/all", &global)
+		if err != nil {
+			failed[country] = err
+			continue
+		}
+		global.Country = "Global"
+		data = append(data, global)
+	}
+
+	if len(names) > 0 {
+		var raw json.RawMessage
+		err := c.getJSONContext(ctx, c.BaseURL+"/countries/"+escapeNames(names), &raw)
+		if err != nil {
+			return data, err
+		}
+		// a single country is returned as an object rather than an array
+		var snapshots Snapshots
+		if trimmed := strings.TrimSpace(string(raw)); strings.HasPrefix(trimmed, "{") {
+			var snapshot Snapshot
+			err = json.Unmarshal(raw, &snapshot)
+			snapshots = append(snapshots, snapshot)
+		} else {
+			err = json.Unmarshal(raw, &snapshots)
+		}
+		if err != nil {
+			return data, err
+		}
+		for i, name := range names {
+			switch {
+			case len(snapshots) == len(names):
+				data = append(data, snapshots[i])
+			case snapshots.find(name) >= 0:
+				data = append(data, snapshots[snapshots.find(name)])
+			default:
+				failed[name] = &notFoundError{"Country not found or doesn't have any cases"}
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return data, failed
+	}
+	return data, nil
+}
+
+//find the index of the snapshot for a country name or iso code, or -1
+func (s Snapshots) find(name string) int {
+	for i, snapshot := range s {
+		for _, n := range []string{snapshot.Country, snapshot.CountryInfo.ISO2, snapshot.CountryInfo.ISO3} {
+			if strings.EqualFold(n, name) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+//Print print the snapshots to an os.File
+func (s Snapshots) Print(output io.Writer, format string) {
+	writeTable(s.toStringArray(), snapshotHeader, output, format)
+}
+
+func (s Snapshots) toStringArray() [][]string {
+	var strData [][]string
+	for _, snapshot := range s {
+		strData = append(strData, []string{
+			snapshot.Country,
+			snapshot.UpdatedTime().Format("2006-01-02 15:04:05"),
+			fmt.Sprintf("%v", snapshot.Cases),
+			fmt.Sprintf("%v", snapshot.TodayCases),
+			fmt.Sprintf("%v", snapshot.Deaths),
+			fmt.Sprintf("%v", snapshot.TodayDeaths),
+			fmt.Sprintf("%v", snapshot.Active),
+			fmt.Sprintf("%v", snapshot.Critical),
+			fmt.Sprintf("%v", snapshot.Tests),
+			fmt.Sprintf("%v", snapshot.Population),
+			strconv.FormatFloat(snapshot.CasesPerOneMillion, 'f', -1, 64),
+			strconv.FormatFloat(snapshot.DeathsPerOneMillion, 'f', -1, 64),
+			strconv.FormatFloat(snapshot.TestsPerOneMillion, 'f', -1, 64),
+		})
+	}
+	return strData
+}
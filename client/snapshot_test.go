@@ -0,0 +1,91 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var snapshotData = `{"updated":1616716800000,"country":"Australia","countryInfo":{"_id":36,"iso2":"AU","iso3":"AUS"},"continent":"Australia-Oceania","cases":29239,"todayCases":9,"deaths":909,"todayDeaths":0,"recovered":26310,"active":2020,"critical":1,"tests":14985005,"population":25788217,"casesPerOneMillion":1134,"deathsPerOneMillion":35,"testsPerOneMillion":581081.5}`
+
+var globalSnapshotData = `{"updated":1616716800000,"cases":125000000,"todayCases":500000,"deaths":2750000,"todayDeaths":9000,"population":7794798729}`
+
+func TestSnapshots(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/countries/australia":
+			w.Write([]byte(snapshotData))
+		case "/countries/aus,azzz":
+			w.Write([]byte("[" + snapshotData + "]"))
+		case "/all":
+			w.Write([]byte(globalSnapshotData))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Country not found or doesn't have any cases"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL + "/historical/%v?lastdays=%v")
+
+	snapshots, err := client.Snapshots([]string{"australia"})
+	assert.NoError(err)
+	assert.Len(snapshots, 1)
+	assert.Equal("Australia", snapshots[0].Country)
+	assert.Equal("AUS", snapshots[0].CountryInfo.ISO3)
+	assert.Equal(25788217, snapshots[0].Population)
+	assert.Equal(time.Date(2021, 3, 26, 0, 0, 0, 0, time.UTC), snapshots[0].UpdatedTime())
+
+	snapshots, err = client.Snapshots([]string{"global", "aus", "azzz"})
+	assert.EqualError(err, "azzz: Country not found or doesn't have any cases")
+	assert.Len(snapshots, 2)
+	assert.Equal("Global", snapshots[0].Country)
+	assert.Equal("Australia", snapshots[1].Country)
+
+	_, err = client.Snapshots([]string{"azzz"})
+	assert.EqualError(err, "Country not found or doesn't have any cases")
+}
+
+func TestPrintSnapshots(t *testing.T) {
+	assert := assert.New(t)
+	snapshots := Snapshots{{
+		Updated:             1616716800000,
+		Country:             "Australia",
+		Cases:               29239,
+		TodayCases:          9,
+		Deaths:              909,
+		Active:              2020,
+		Critical:            1,
+		Tests:               14985005,
+		Population:          25788217,
+		CasesPerOneMillion:  1134,
+		DeathsPerOneMillion: 35,
+		TestsPerOneMillion:  581081.5,
+	}}
+	buf := new(bytes.Buffer)
+	snapshots.Print(buf, "csv")
+	assert.Equal("Country,Updated,Cases,Today Cases,Deaths,Today Deaths,Active,Critical,Tests,Population,Cases Per Million,Deaths Per Million,Tests Per Million\nAustralia,2021-03-26 00:00:00,29239,9,909,0,2020,1,14985005,25788217,1134,35,581081.5\n", buf.String())
+}
@@ -0,0 +1,163 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+//DataSource a provider of daily covid numbers for a country. Each capability of a source is a single method
+//taking a context, which the sources also have without the context for calling them directly
+type DataSource interface {
+	//SeriesContext returns the time series for country between from and to (or only the latest day), giving up when ctx is cancelled
+	SeriesContext(ctx context.Context, country string, from, to time.Time, latest bool) (TimeSeries, error)
+}
+
+//BatchSource a DataSource which can fetch several countries with a single request
+type BatchSource interface {
+	DataSource
+	//SeriesBatchContext returns the series in the order of countries, any country which failed is left out and reported in a SeriesError
+	SeriesBatchContext(ctx context.Context, countries []string, from, to time.Time, latest bool) ([]TimeSeries, error)
+}
+
+//ProvinceSource a DataSource which also has data for the provinces (or states) of a country
+type ProvinceSource interface {
+	DataSource
+	//ProvinceSeriesContext returns a series for each province, any province which failed is left out and reported in a SeriesError
+	ProvinceSeriesContext(ctx context.Context, country string, provinces []string, from, to time.Time, latest bool) ([]TimeSeries, error)
+	//ProvincesContext lists the provinces of a country
+	ProvincesContext(ctx context.Context, country string) (Provinces, error)
+}
+
+//VaccineSource a source of the total vaccine doses given in a country
+type VaccineSource interface {
+	//VaccinesContext returns a time series which only has the Doses of each day
+	VaccinesContext(ctx context.Context, country string, from, to time.Time, latest bool) (TimeSeries, error)
+}
+
+//CountrySource a source which can list the countries it has data for
+type CountrySource interface {
+	CountriesContext(ctx context.Context) (CountryListings, error)
+}
+
+//ContinentSource a source of the member countries of a continent
+type ContinentSource interface {
+	ContinentContext(ctx context.Context, name string) (Continent, error)
+}
+
+//Series returns the time series for a country from the disease.sh api
+func (c *APIClient) Series(country string, from, to time.Time, latest bool) (TimeSeries, error) {
+	return c.SeriesContext(context.Background(), country, from, to, latest)
+}
+
+//SeriesContext returns the time series for a country from the disease.sh api, giving up when ctx is cancelled
+func (c *APIClient) SeriesContext(ctx context.Context, country string, from, to time.Time, latest bool) (TimeSeries, error) {
+	res, err := c.GetContext(ctx, country, from, to, latest)
+	if err != nil && !partialSeries(err) {
+		return TimeSeries{}, err
+	}
+	return res.TimeSeries, err
+}
+
+//SeriesBatch returns the time series for several countries with a single request to the disease.sh api
+func (c *APIClient) SeriesBatch(countries []string, from, to time.Time, latest bool) ([]TimeSeries, error) {
+	return c.SeriesBatchContext(context.Background(), countries, from, to, latest)
+}
+
+//SeriesBatchContext returns the time series for several countries with a single request, giving up when ctx is
+//cancelled. The world totals have a different response, so the reserved global names are requested on their own
+func (c *APIClient) SeriesBatchContext(ctx context.Context, countries []string, from, to time.Time, latest bool) ([]TimeSeries, error) {
+	var names []string
+	for _, country := range countries {
+		if !IsGlobal(country) {
+			names = append(names, country)
+		}
+	}
+	var res []APIResponse
+	failed := SeriesError{}
+	if len(names) > 0 {
+		var err error
+		res, err = c.GetBatchContext(ctx, names, from, to, latest)
+		if len(names) == len(countries) {
+			var series []TimeSeries
+			for _, r := range res {
+				series = append(series, r.TimeSeries)
+			}
+			return series, err
+		}
+		// the whole request failing (e.g. none of the names were found) fails each of them
+		if err != nil && !errors.As(err, &failed) {
+			for _, name := range names {
+				failed[name] = err
+			}
+		}
+	}
+
+	// the batch has left out the names which failed, so the rest are matched up with it in order
+	var series []TimeSeries
+	next := 0
+	for _, country := range countries {
+		if IsGlobal(country) {
+			ts, err := c.SeriesContext(ctx, country, from, to, latest)
+			if err != nil {
+				failed[country] = err
+				if !partialSeries(err) {
+					continue
+				}
+			}
+			series = append(series, ts)
+			continue
+		}
+		if err, ok := failed[country]; ok && !partialSeries(err) {
+			continue
+		}
+		series = append(series, res[next].TimeSeries)
+		next++
+	}
+	if len(failed) > 0 {
+		return series, failed
+	}
+	return series, nil
+}
+
+//ProvinceSeries returns the time series for provinces of a country with a single request to the disease.sh api
+func (c *APIClient) ProvinceSeries(country string, provinces []string, from, to time.Time, latest bool) ([]TimeSeries, error) {
+	return c.ProvinceSeriesContext(context.Background(), country, provinces, from, to, latest)
+}
+
+//ProvinceSeriesContext returns the time series for provinces of a country with a single request, giving up when ctx is cancelled
+func (c *APIClient) ProvinceSeriesContext(ctx context.Context, country string, provinces []string, from, to time.Time, latest bool) ([]TimeSeries, error) {
+	res, err := c.GetProvincesContext(ctx, country, provinces, from, to, latest)
+	var series []TimeSeries
+	for _, r := range res {
+		series = append(series, r.TimeSeries)
+	}
+	return series, err
+}
+
+//Provinces lists the provinces the disease.sh api has data for
+func (c *APIClient) Provinces(country string) (Provinces, error) {
+	return c.ProvincesContext(context.Background(), country)
+}
+
+//ProvincesContext lists the provinces the disease.sh api has data for, giving up when ctx is cancelled
+func (c *APIClient) ProvincesContext(ctx context.Context, country string) (Provinces, error) {
+	res, err := c.GetContext(ctx, country, time.Now(), time.Now(), true)
+	return res.Province, err
+}
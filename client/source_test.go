@@ -0,0 +1,92 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIClientSeries(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.Contains(strings.ToLower(r.URL.Path), "australia") {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"country not found"}`))
+			return
+		}
+		w.Write([]byte(responseData))
+	}))
+	defer server.Close()
+
+	var source DataSource = NewClient(server.URL + "/%v%v")
+
+	day := time.Date(2021, 3, 24, 0, 0, 0, 0, time.UTC)
+	ts, err := source.SeriesContext(context.Background(), "australia", day, day, false)
+	assert.NoError(err)
+	assert.Equal([]Day{{Country: "Australia", Date: day, Cases: 29230, Deaths: 909, Recovered: 22988}}, ts.Data)
+
+	ts, err = source.SeriesContext(context.Background(), "azzz", day, day, false)
+	assert.EqualError(err, "country not found")
+	assert.Empty(ts.Data)
+}
+
+func TestAPIClientSeriesBatch(t *testing.T) {
+	assert := assert.New(t)
+
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		paths = append(paths, strings.TrimRight(r.URL.Path, "0123456789"))
+		switch strings.TrimRight(r.URL.Path, "0123456789") {
+		case "/all":
+			w.Write([]byte(globalResponseData))
+		case "/australia,azzz":
+			w.Write([]byte("[" + responseData + `,{"message":"Country not found or doesn't have any historical data"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Country not found or doesn't have any historical data"}`))
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL + "/%v%v")
+	day := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+
+	// the world is requested on its own, and the rest are still in the order asked for
+	series, err := client.SeriesBatch([]string{"australia", "global", "azzz"}, day, day, false)
+	assert.EqualError(err, "azzz: Country not found or doesn't have any historical data")
+	assert.Equal([]string{"/australia,azzz", "/all"}, paths)
+	var countries []string
+	for _, ts := range series {
+		countries = append(countries, ts.Data[0].Country)
+	}
+	assert.Equal([]string{"Australia", "Global"}, countries)
+
+	// nor is the world lost when none of the others are found
+	series, err = client.SeriesBatch([]string{"azzz", "all"}, day, day, false)
+	assert.EqualError(err, "azzz: Country not found or doesn't have any historical data")
+	assert.Len(series, 1)
+	assert.Equal("Global", series[0].Data[0].Country)
+}
@@ -18,9 +18,12 @@ package client
 
 import (
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/olekukonko/tablewriter"
@@ -30,18 +33,45 @@ var (
 	header = []string{"Date", "Cases", "Deaths", "Recovered"}
 )
 
+//column an optional output column, only printed when at least one day has a value
+type column struct {
+	name  string
+	value func(Day) int
+}
+
+var optionalColumns = []column{
+	{name: "Tests", value: func(d Day) int { return d.Tests }},
+	{name: "Hospitalised", value: func(d Day) int { return d.Hospitalised }},
+	{name: "Doses", value: func(d Day) int { return d.Doses }},
+}
+
 //Day holds all the values for a given day
 type Day struct {
-	Country   string
-	Date      time.Time
-	Cases     int
-	Deaths    int
-	Recovered int
+	Country          string
+	Province         string // Empty for the whole country
+	County           string // Only used for US counties
+	Date             time.Time
+	Cases            int
+	Deaths           int
+	Recovered        int
+	Tests            int     // Not provided by every source
+	Hospitalised     int     // Not provided by every source
+	Doses            int     // Not provided by every source
+	Rolling          int     // The days CasesAverage and DeathsAverage are over, 0 when the day doesn't have them
+	CasesAverage     float64 // The average daily new cases
+	DeathsAverage    float64 // The average daily new deaths
+	Per              int     // The people CasesPer and DeathsPer are per, 0 when the day doesn't have them
+	CasesPer         float64 // The cumulative cases per Per people
+	DeathsPer        float64 // The cumulative deaths per Per people
+	RateDays         int     // The days NotificationRate is over, 0 when the day doesn't have it
+	NotificationRate float64 // The new cases over RateDays per 100,000 people
+	Note             string  // Anything unusual about the day, e.g. a correction to an earlier day
 }
 
 //TimeSeries holds a slice of days
 type TimeSeries struct {
-	Data []Day // A slice of daily data
+	Data        []Day // A slice of daily data
+	ShowCountry bool  // Print the Country column even when the days are all of one country, e.g. as others failed
 }
 
 //Order the data in chronological order
@@ -55,7 +85,9 @@ func (ts *TimeSeries) Order() {
 //Filter filter the time series data based on from, to or latest
 func (ts *TimeSeries) Filter(from, to time.Time, latest bool) {
 	if latest {
-		ts.Data = ts.Data[(len(ts.Data) - 1):]
+		if len(ts.Data) > 0 {
+			ts.Data = ts.Data[(len(ts.Data) - 1):]
+		}
 		return
 	}
 	filteredTS := []Day{}
@@ -69,27 +101,416 @@ func (ts *TimeSeries) Filter(from, to time.Time, latest bool) {
 	ts.Data = filteredTS
 }
 
+//Daily turns the cumulative values into the change on the day before, for each country, province and county.
+//The first day of each has no day before so is left out, so fetch one day before the first day wanted. A value
+//which goes down (a correction to earlier days) is kept negative and noted. Hospitalised isn't cumulative so
+//is left as is
+func (ts *TimeSeries) Daily() {
+	type key struct {
+		country, province, county string
+	}
+	// a cumulative value can't go back to 0, so a 0 is missing rather than a change
+	change := func(today, before int) int {
+		if today == 0 {
+			return 0
+		}
+		return today - before
+	}
+	// the values not every source provides are often missing for days at a time, so are only a change
+	// between two days which both have them
+	optionalChange := func(today, before int) int {
+		if before == 0 {
+			return 0
+		}
+		return change(today, before)
+	}
+	previous := map[key]Day{}
+	daily := []Day{}
+	for _, obs := range ts.Data {
+		k := key{obs.Country, obs.Province, obs.County}
+		before, ok := previous[k]
+		previous[k] = obs
+		if !ok {
+			continue
+		}
+		day := obs
+		day.Cases = change(obs.Cases, before.Cases)
+		day.Deaths = change(obs.Deaths, before.Deaths)
+		day.Recovered = change(obs.Recovered, before.Recovered)
+		day.Tests = optionalChange(obs.Tests, before.Tests)
+		day.Doses = optionalChange(obs.Doses, before.Doses)
+		var corrected []string
+		for _, col := range []struct {
+			name  string
+			value int
+		}{{"cases", day.Cases}, {"deaths", day.Deaths}, {"recovered", day.Recovered}, {"tests", day.Tests}, {"doses", day.Doses}} {
+			if col.value < 0 {
+				corrected = append(corrected, col.name)
+			}
+		}
+		if len(corrected) > 0 {
+			day.Note = "correction: " + strings.Join(corrected, ", ") + " revised down"
+		}
+		daily = append(daily, day)
+	}
+	ts.Data = daily
+}
+
+//Join copies the vaccine doses of other onto the days of the series with the same country and date
+func (ts *TimeSeries) Join(other TimeSeries) {
+	type key struct {
+		country string
+		date    time.Time
+	}
+	doses := map[key]int{}
+	for _, obs := range other.Data {
+		doses[key{obs.Country, obs.Date}] = obs.Doses
+	}
+	for i, obs := range ts.Data {
+		if d, ok := doses[key{obs.Country, obs.Date}]; ok {
+			ts.Data[i].Doses = d
+		}
+	}
+}
+
+//Changed the days which aren't in prev or have different values to the same day in prev, in the order of the series
+func (ts *TimeSeries) Changed(prev TimeSeries) TimeSeries {
+	type key struct {
+		country, province, county string
+		date                      int64
+	}
+	keyOf := func(obs Day) key {
+		return key{obs.Country, obs.Province, obs.County, obs.Date.Unix()}
+	}
+	previous := map[key]Day{}
+	for _, obs := range prev.Data {
+		previous[keyOf(obs)] = obs
+	}
+	var changed TimeSeries
+	for _, obs := range ts.Data {
+		old, ok := previous[keyOf(obs)]
+		// the dates are part of the key, and may only differ in their location
+		old.Date = obs.Date
+		if !ok || old != obs {
+			changed.Data = append(changed.Data, obs)
+		}
+	}
+	return changed
+}
+
+//Rolling sets the average daily new cases and deaths over days on each day which has enough days around it, for each
+//country, province and county. The averages are the change of the cumulative values, so Rolling goes before Daily.
+//A trailing average is over the day and the days before it, a centred one over the days either side of it. The
+//window is found by date, so a source which skips days still averages over days calendar days, and a day whose
+//window doesn't start and end on a day of the series has no average
+func (ts *TimeSeries) Rolling(days int, centred bool) {
+	if days < 1 {
+		return
+	}
+	type key struct {
+		country, province, county string
+		date                      int64
+	}
+	keyOf := func(obs Day, date time.Time) key {
+		return key{obs.Country, obs.Province, obs.County, date.Unix()}
+	}
+	index := map[key]int{}
+	for i, obs := range ts.Data {
+		index[keyOf(obs, obs.Date)] = i
+	}
+	for i, obs := range ts.Data {
+		last := obs.Date
+		if centred {
+			last = last.AddDate(0, 0, days/2)
+		}
+		// the values are cumulative, so only the days either end of the window are needed
+		first, okFirst := index[keyOf(obs, last.AddDate(0, 0, -days))]
+		end, okEnd := index[keyOf(obs, last)]
+		if !okFirst || !okEnd {
+			continue
+		}
+		ts.Data[i].Rolling = days
+		ts.Data[i].CasesAverage = float64(ts.Data[end].Cases-ts.Data[first].Cases) / float64(days)
+		ts.Data[i].DeathsAverage = float64(ts.Data[end].Deaths-ts.Data[first].Deaths) / float64(days)
+	}
+}
+
+//RollingLeadIn the days before and after a range of days needed for the averages of Rolling on every day of it
+func RollingLeadIn(days int, centred bool) (before, after int) {
+	if !centred {
+		return days, 0
+	}
+	return days - days/2, days / 2
+}
+
+//NewCasesAverage the average daily new cases over the last days of the series, from the difference of the
+//cumulative cases. It needs the last day and the day days before it (by date, like Rolling) from a single
+//country, otherwise ok is false
+func (ts *TimeSeries) NewCasesAverage(days int) (avg float64, ok bool) {
+	if days < 1 || len(ts.Data) == 0 {
+		return 0, false
+	}
+	last := ts.Data[len(ts.Data)-1]
+	start := last.Date.AddDate(0, 0, -days)
+	for _, obs := range ts.Data {
+		if obs.Date.Equal(start) {
+			return float64(last.Cases-obs.Cases) / float64(days), true
+		}
+	}
+	return 0, false
+}
+
 //Print print the timeseries data to an os.File
 func (ts *TimeSeries) Print(output io.Writer, format string) {
-	exportData := ts.toStringArray()
+	writeTable(ts.toStringArray(), ts.header(), output, format)
+}
+
+//jsonDay a day in the json output. The values which not every source provides are left out when they are zero
+type jsonDay struct {
+	Country          string  `json:"country"`
+	Province         string  `json:"province,omitempty"`
+	County           string  `json:"county,omitempty"`
+	Date             string  `json:"date"`
+	Cases            int     `json:"cases"`
+	Deaths           int     `json:"deaths"`
+	Recovered        int     `json:"recovered"`
+	Tests            int     `json:"tests,omitempty"`
+	Hospitalised     int     `json:"hospitalised,omitempty"`
+	Doses            int     `json:"doses,omitempty"`
+	Rolling          int     `json:"rolling,omitempty"`
+	CasesAverage     float64 `json:"cases_avg,omitempty"`
+	DeathsAverage    float64 `json:"deaths_avg,omitempty"`
+	Per              int     `json:"per,omitempty"`
+	CasesPer         float64 `json:"cases_per,omitempty"`
+	DeathsPer        float64 `json:"deaths_per,omitempty"`
+	RateDays         int     `json:"rate_days,omitempty"`
+	NotificationRate float64 `json:"notification_rate,omitempty"`
+	Note             string  `json:"note,omitempty"`
+}
+
+//WriteJSON writes the days as a json array, with the dates as 2006-01-02
+func (ts *TimeSeries) WriteJSON(output io.Writer) error {
+	days := make([]jsonDay, 0, len(ts.Data))
+	for _, obs := range ts.Data {
+		days = append(days, jsonDay{
+			Country:          obs.Country,
+			Province:         obs.Province,
+			County:           obs.County,
+			Date:             obs.Date.Format("2006-01-02"),
+			Cases:            obs.Cases,
+			Deaths:           obs.Deaths,
+			Recovered:        obs.Recovered,
+			Tests:            obs.Tests,
+			Hospitalised:     obs.Hospitalised,
+			Doses:            obs.Doses,
+			Rolling:          obs.Rolling,
+			CasesAverage:     obs.CasesAverage,
+			DeathsAverage:    obs.DeathsAverage,
+			Per:              obs.Per,
+			CasesPer:         obs.CasesPer,
+			DeathsPer:        obs.DeathsPer,
+			RateDays:         obs.RateDays,
+			NotificationRate: obs.NotificationRate,
+			Note:             obs.Note,
+		})
+	}
+	return json.NewEncoder(output).Encode(days)
+}
+
+//writeTable writes the table in the requested format, defaulting to markdown
+func writeTable(data [][]string, header []string, output io.Writer, format string) {
 	switch format {
 	case "csv":
-		writeCSV(exportData, header, output)
+		writeCSV(data, header, output)
 	default:
-		writeMarkdown(exportData, header, output)
+		writeMarkdown(data, header, output)
+	}
+}
+
+func (ts *TimeSeries) header() []string {
+	var tsHeader []string
+	if ts.multipleCountries() {
+		tsHeader = append(tsHeader, "Country")
 	}
+	if ts.hasProvinces() {
+		tsHeader = append(tsHeader, "Province")
+	}
+	if ts.hasCounties() {
+		tsHeader = append(tsHeader, "County")
+	}
+	if ts.vaccinesOnly() {
+		tsHeader = append(tsHeader, header[0])
+	} else {
+		tsHeader = append(tsHeader, header...)
+	}
+	for _, col := range ts.optionalColumns() {
+		tsHeader = append(tsHeader, col.name)
+	}
+	if rolling := ts.rolling(); rolling > 0 {
+		tsHeader = append(tsHeader, fmt.Sprintf("Cases %vd Avg", rolling), fmt.Sprintf("Deaths %vd Avg", rolling))
+	}
+	if per := ts.per(); per > 0 {
+		tsHeader = append(tsHeader, "Cases per "+PerLabel(per), "Deaths per "+PerLabel(per))
+	}
+	if days := ts.rateDays(); days > 0 {
+		tsHeader = append(tsHeader, fmt.Sprintf("%vd Rate per 100k", days))
+	}
+	if ts.hasNotes() {
+		tsHeader = append(tsHeader, "Note")
+	}
+	return tsHeader
+}
+
+//hasProvinces whether any of the days are for a province rather than a whole country
+func (ts *TimeSeries) hasProvinces() bool {
+	for _, obs := range ts.Data {
+		if obs.Province != "" {
+			return true
+		}
+	}
+	return false
+}
+
+//hasCounties whether any of the days are for a US county
+func (ts *TimeSeries) hasCounties() bool {
+	for _, obs := range ts.Data {
+		if obs.County != "" {
+			return true
+		}
+	}
+	return false
+}
+
+//rolling the days the averages are over, or 0 if none of the days have averages
+func (ts *TimeSeries) rolling() int {
+	for _, obs := range ts.Data {
+		if obs.Rolling > 0 {
+			return obs.Rolling
+		}
+	}
+	return 0
+}
+
+//per the people the per capita values are per, or 0 if none of the days have them
+func (ts *TimeSeries) per() int {
+	for _, obs := range ts.Data {
+		if obs.Per > 0 {
+			return obs.Per
+		}
+	}
+	return 0
+}
+
+//rateDays the days the notification rate is over, or 0 if none of the days have it
+func (ts *TimeSeries) rateDays() int {
+	for _, obs := range ts.Data {
+		if obs.RateDays > 0 {
+			return obs.RateDays
+		}
+	}
+	return 0
+}
+
+//hasNotes whether any of the days has a note
+func (ts *TimeSeries) hasNotes() bool {
+	for _, obs := range ts.Data {
+		if obs.Note != "" {
+			return true
+		}
+	}
+	return false
+}
 
+//vaccinesOnly whether the series only has vaccine doses, in which case the cases, deaths and recovered aren't printed
+func (ts *TimeSeries) vaccinesOnly() bool {
+	doses := false
+	for _, obs := range ts.Data {
+		if obs.Cases != 0 || obs.Deaths != 0 || obs.Recovered != 0 || obs.Tests != 0 || obs.Hospitalised != 0 {
+			return false
+		}
+		doses = doses || obs.Doses != 0
+	}
+	return doses
+}
+
+//multipleCountries whether the series holds days for more than one country, or is to be printed as if it did
+func (ts *TimeSeries) multipleCountries() bool {
+	if ts.ShowCountry {
+		return true
+	}
+	for _, obs := range ts.Data {
+		if obs.Country != ts.Data[0].Country {
+			return true
+		}
+	}
+	return false
+}
+
+func (ts *TimeSeries) optionalColumns() []column {
+	var cols []column
+	for _, col := range optionalColumns {
+		for _, obs := range ts.Data {
+			if col.value(obs) != 0 {
+				cols = append(cols, col)
+				break
+			}
+		}
+	}
+	return cols
 }
 
 func (ts *TimeSeries) toStringArray() [][]string {
 	var strData [][]string
+	cols := ts.optionalColumns()
+	countries := ts.multipleCountries()
+	provinces := ts.hasProvinces()
+	counties := ts.hasCounties()
+	vaccinesOnly := ts.vaccinesOnly()
+	rolling := ts.rolling() > 0
+	perCapita := ts.per() > 0
+	rate := ts.rateDays() > 0
+	notes := ts.hasNotes()
 	for _, obs := range ts.Data {
-		strData = append(strData, []string{
-			obs.Date.Format("2006-01-02"),
-			fmt.Sprintf("%v", obs.Cases),
-			fmt.Sprintf("%v", obs.Deaths),
-			fmt.Sprintf("%v", obs.Recovered),
-		})
+		var row []string
+		if countries {
+			row = append(row, obs.Country)
+		}
+		if provinces {
+			row = append(row, obs.Province)
+		}
+		if counties {
+			row = append(row, obs.County)
+		}
+		row = append(row, obs.Date.Format("2006-01-02"))
+		if !vaccinesOnly {
+			row = append(row,
+				fmt.Sprintf("%v", obs.Cases),
+				fmt.Sprintf("%v", obs.Deaths),
+				fmt.Sprintf("%v", obs.Recovered),
+			)
+		}
+		for _, col := range cols {
+			row = append(row, fmt.Sprintf("%v", col.value(obs)))
+		}
+		if rolling && obs.Rolling == 0 {
+			row = append(row, "", "")
+		} else if rolling {
+			row = append(row, strconv.FormatFloat(obs.CasesAverage, 'f', 1, 64), strconv.FormatFloat(obs.DeathsAverage, 'f', 1, 64))
+		}
+		if perCapita && obs.Per == 0 {
+			row = append(row, "", "")
+		} else if perCapita {
+			row = append(row, strconv.FormatFloat(obs.CasesPer, 'f', 2, 64), strconv.FormatFloat(obs.DeathsPer, 'f', 2, 64))
+		}
+		if rate && obs.RateDays == 0 {
+			row = append(row, "")
+		} else if rate {
+			row = append(row, strconv.FormatFloat(obs.NotificationRate, 'f', 1, 64))
+		}
+		if notes {
+			row = append(row, obs.Note)
+		}
+		strData = append(strData, row)
 	}
 	return strData
 }
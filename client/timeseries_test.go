@@ -33,14 +33,14 @@ func TestOrder(t *testing.T) {
 	}{
 		{
 			in: TimeSeries{
-				[]Day{
+				Data: []Day{
 					{Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
 					{Date: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)},
 					{Date: time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC)},
 				},
 			},
 			expected: TimeSeries{
-				[]Day{
+				Data: []Day{
 					{Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
 					{Date: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)},
 					{Date: time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC)},
@@ -49,14 +49,14 @@ func TestOrder(t *testing.T) {
 		},
 		{
 			in: TimeSeries{
-				[]Day{
+				Data: []Day{
 					{Date: time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC)},
 					{Date: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)},
 					{Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
 				},
 			},
 			expected: TimeSeries{
-				[]Day{
+				Data: []Day{
 					{Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
 					{Date: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)},
 					{Date: time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC)},
@@ -89,14 +89,14 @@ func TestFilter(t *testing.T) {
 			to:     time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
 			latest: false,
 			data: TimeSeries{
-				[]Day{
+				Data: []Day{
 					{Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
 					{Date: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)},
 					{Date: time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC)},
 				},
 			},
 			expected: TimeSeries{
-				[]Day{
+				Data: []Day{
 					{Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
 				},
 			},
@@ -106,14 +106,14 @@ func TestFilter(t *testing.T) {
 			to:     time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC),
 			latest: false,
 			data: TimeSeries{
-				[]Day{
+				Data: []Day{
 					{Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
 					{Date: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)},
 					{Date: time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC)},
 				},
 			},
 			expected: TimeSeries{
-				[]Day{
+				Data: []Day{
 					{Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
 					{Date: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)},
 				},
@@ -124,20 +124,28 @@ func TestFilter(t *testing.T) {
 			to:     time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC),
 			latest: true,
 			data: TimeSeries{
-				[]Day{
+				Data: []Day{
 					{Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
 					{Date: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)},
 					{Date: time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC)},
 				},
 			},
 			expected: TimeSeries{
-				[]Day{
+				Data: []Day{
 					{Date: time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC)},
 				},
 			},
 		},
 	}
 
+	tests = append(tests, struct {
+		from     time.Time
+		to       time.Time
+		latest   bool
+		data     TimeSeries
+		expected TimeSeries
+	}{latest: true})
+
 	for _, test := range tests {
 		test.data.Filter(test.from, test.to, test.latest)
 		assert.Equal(test.expected, test.data)
@@ -170,7 +178,7 @@ func TestToStringArray(t *testing.T) {
 	}{
 		{
 			in: TimeSeries{
-				[]Day{
+				Data: []Day{
 					{Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Cases: 1, Deaths: 2, Recovered: 3},
 					{Date: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC), Cases: 4, Deaths: 5, Recovered: 6},
 					{Date: time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC), Cases: 7, Deaths: 8, Recovered: 9},
@@ -198,7 +206,7 @@ func TestPrint(t *testing.T) {
 	}{
 		{
 			in: TimeSeries{
-				[]Day{
+				Data: []Day{
 					{Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Cases: 1, Deaths: 2, Recovered: 3},
 					{Date: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC), Cases: 4, Deaths: 5, Recovered: 6},
 					{Date: time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC), Cases: 7, Deaths: 8, Recovered: 9},
@@ -209,7 +217,7 @@ func TestPrint(t *testing.T) {
 		},
 		{
 			in: TimeSeries{
-				[]Day{
+				Data: []Day{
 					{Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Cases: 1, Deaths: 2, Recovered: 3},
 					{Date: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC), Cases: 4, Deaths: 5, Recovered: 6},
 					{Date: time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC), Cases: 7, Deaths: 8, Recovered: 9},
@@ -221,7 +229,7 @@ func TestPrint(t *testing.T) {
 
 		{
 			in: TimeSeries{
-				[]Day{
+				Data: []Day{
 					{Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Cases: 1, Deaths: 2, Recovered: 3},
 					{Date: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC), Cases: 4, Deaths: 5, Recovered: 6},
 					{Date: time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC), Cases: 7, Deaths: 8, Recovered: 9},
@@ -230,6 +238,46 @@ func TestPrint(t *testing.T) {
 			format:   "csv",
 			expected: "Date,Cases,Deaths,Recovered\n2021-01-01,1,2,3\n2021-01-02,4,5,6\n2021-01-03,7,8,9\n",
 		},
+		{
+			in: TimeSeries{
+				Data: []Day{
+					{Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Cases: 1, Deaths: 2, Tests: 10},
+					{Date: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC), Cases: 4, Deaths: 5, Tests: 20, Doses: 3},
+				},
+			},
+			format:   "csv",
+			expected: "Date,Cases,Deaths,Recovered,Tests,Doses\n2021-01-01,1,2,0,10,0\n2021-01-02,4,5,0,20,3\n",
+		},
+		{
+			in: TimeSeries{
+				Data: []Day{
+					{Country: "Australia", Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Cases: 1, Deaths: 2, Recovered: 3},
+					{Country: "New Zealand", Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Cases: 4, Deaths: 5, Recovered: 6},
+				},
+			},
+			format:   "csv",
+			expected: "Country,Date,Cases,Deaths,Recovered\nAustralia,2021-01-01,1,2,3\nNew Zealand,2021-01-01,4,5,6\n",
+		},
+		{
+			in: TimeSeries{
+				Data: []Day{
+					{Country: "Australia", Province: "victoria", Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Cases: 1, Deaths: 2, Recovered: 3},
+					{Country: "Australia", Province: "queensland", Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Cases: 4, Deaths: 5, Recovered: 6},
+				},
+			},
+			format:   "csv",
+			expected: "Province,Date,Cases,Deaths,Recovered\nvictoria,2021-01-01,1,2,3\nqueensland,2021-01-01,4,5,6\n",
+		},
+		{
+			in: TimeSeries{
+				Data: []Day{
+					{Country: "Australia", Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
+					{Country: "Australia", Date: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC), Doses: 10},
+				},
+			},
+			format:   "csv",
+			expected: "Date,Doses\n2021-01-01,0\n2021-01-02,10\n",
+		},
 	}
 	for _, test := range tests {
 		buf := new(bytes.Buffer)
@@ -239,3 +287,187 @@ func TestPrint(t *testing.T) {
 
 	}
 }
+
+func TestJoin(t *testing.T) {
+	assert := assert.New(t)
+	day := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := TimeSeries{
+		Data: []Day{
+			{Country: "Australia", Date: day, Cases: 1},
+			{Country: "Australia", Date: day.AddDate(0, 0, 1), Cases: 2},
+			{Country: "New Zealand", Date: day, Cases: 3},
+		},
+	}
+	ts.Join(TimeSeries{
+		Data: []Day{
+			{Country: "Australia", Date: day, Doses: 10},
+			{Country: "New Zealand", Date: day.AddDate(0, 0, 1), Doses: 20},
+		},
+	})
+	assert.Equal([]Day{
+		{Country: "Australia", Date: day, Cases: 1, Doses: 10},
+		{Country: "Australia", Date: day.AddDate(0, 0, 1), Cases: 2},
+		{Country: "New Zealand", Date: day, Cases: 3},
+	}, ts.Data)
+}
+
+func TestWriteJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := TimeSeries{Data: []Day{
+		{Country: "Australia", Date: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC), Cases: 29239, Deaths: 909, Recovered: 22991},
+		{Country: "Australia", Province: "Victoria", Date: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC), Cases: 20483, Tests: 10},
+	}}
+	buf := new(bytes.Buffer)
+	assert.NoError(ts.WriteJSON(buf))
+	assert.Equal(`[{"country":"Australia","date":"2021-03-25","cases":29239,"deaths":909,"recovered":22991},{"country":"Australia","province":"Victoria","date":"2021-03-25","cases":20483,"deaths":0,"recovered":0,"tests":10}]`+"\n", buf.String())
+
+	buf.Reset()
+	empty := TimeSeries{}
+	assert.NoError(empty.WriteJSON(buf))
+	assert.Equal("[]\n", buf.String())
+}
+
+func TestNewCasesAverage(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := TimeSeries{Data: []Day{
+		{Date: time.Date(2021, 3, 23, 0, 0, 0, 0, time.UTC), Cases: 100},
+		{Date: time.Date(2021, 3, 24, 0, 0, 0, 0, time.UTC), Cases: 110},
+		{Date: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC), Cases: 125},
+	}}
+	avg, ok := ts.NewCasesAverage(2)
+	assert.True(ok)
+	assert.Equal(12.5, avg)
+
+	avg, ok = ts.NewCasesAverage(1)
+	assert.True(ok)
+	assert.Equal(15.0, avg)
+
+	_, ok = ts.NewCasesAverage(3)
+	assert.False(ok)
+	_, ok = ts.NewCasesAverage(0)
+	assert.False(ok)
+
+	// a skipped day doesn't widen the average
+	ts.Data = ts.Data[1:]
+	ts.Data[0].Date = time.Date(2021, 3, 22, 0, 0, 0, 0, time.UTC)
+	_, ok = ts.NewCasesAverage(1)
+	assert.False(ok)
+	avg, ok = ts.NewCasesAverage(3)
+	assert.True(ok)
+	assert.Equal(5.0, avg)
+}
+
+func TestChanged(t *testing.T) {
+	assert := assert.New(t)
+
+	day := func(country string, date, cases int) Day {
+		return Day{Country: country, Date: time.Date(2021, 3, date, 0, 0, 0, 0, time.UTC), Cases: cases}
+	}
+	prev := TimeSeries{Data: []Day{day("Australia", 23, 100), day("Australia", 24, 110), day("New Zealand", 24, 50)}}
+	current := TimeSeries{Data: []Day{day("Australia", 23, 100), day("Australia", 24, 112), day("Australia", 25, 120), day("New Zealand", 24, 50)}}
+
+	assert.Equal(TimeSeries{Data: []Day{day("Australia", 24, 112), day("Australia", 25, 120)}}, current.Changed(prev))
+	assert.Equal(current, current.Changed(TimeSeries{}))
+	assert.Equal(TimeSeries{}, current.Changed(current))
+}
+
+func TestDaily(t *testing.T) {
+	assert := assert.New(t)
+
+	date := func(day int) time.Time {
+		return time.Date(2021, 3, day, 0, 0, 0, 0, time.UTC)
+	}
+	ts := TimeSeries{Data: []Day{
+		{Country: "Australia", Date: date(22), Cases: 100, Deaths: 10, Recovered: 50, Hospitalised: 7},
+		{Country: "Australia", Date: date(23), Cases: 105, Deaths: 10, Recovered: 55, Hospitalised: 8},
+		{Country: "Australia", Date: date(24), Cases: 103, Deaths: 11, Recovered: 0, Hospitalised: 6},
+		{Country: "Australia", Province: "Victoria", Date: date(23), Cases: 20, Tests: 250, Doses: 0},
+		{Country: "Australia", Province: "Victoria", Date: date(24), Cases: 22, Tests: 300, Doses: 40},
+	}}
+	ts.Daily()
+	assert.Equal(TimeSeries{Data: []Day{
+		{Country: "Australia", Date: date(23), Cases: 5, Deaths: 0, Recovered: 5, Hospitalised: 8},
+		{Country: "Australia", Date: date(24), Cases: -2, Deaths: 1, Recovered: 0, Hospitalised: 6, Note: "correction: cases revised down"},
+		{Country: "Australia", Province: "Victoria", Date: date(24), Cases: 2, Tests: 50},
+	}}, ts)
+
+	buf := new(bytes.Buffer)
+	ts.Print(buf, "csv")
+	assert.Equal("Province,Date,Cases,Deaths,Recovered,Tests,Hospitalised,Note\n"+
+		",2021-03-23,5,0,5,0,8,\n"+
+		",2021-03-24,-2,1,0,0,6,correction: cases revised down\n"+
+		"Victoria,2021-03-24,2,0,0,50,0,\n", buf.String())
+
+	empty := TimeSeries{Data: []Day{{Country: "Australia", Date: date(22), Cases: 100}}}
+	empty.Daily()
+	assert.Empty(empty.Data)
+}
+
+func TestRolling(t *testing.T) {
+	assert := assert.New(t)
+
+	date := func(day int) time.Time {
+		return time.Date(2021, 3, day, 0, 0, 0, 0, time.UTC)
+	}
+	series := func() TimeSeries {
+		var ts TimeSeries
+		for i, cases := range []int{100, 103, 109, 112, 118, 121} {
+			ts.Data = append(ts.Data, Day{Country: "Australia", Date: date(20 + i), Cases: cases, Deaths: i})
+		}
+		return ts
+	}
+
+	trailing := series()
+	trailing.Rolling(3, false)
+	var averages [][2]float64
+	for _, obs := range trailing.Data {
+		averages = append(averages, [2]float64{float64(obs.Rolling), obs.CasesAverage})
+	}
+	assert.Equal([][2]float64{{0, 0}, {0, 0}, {0, 0}, {3, 4}, {3, 5}, {3, 4}}, averages)
+	assert.Equal(1.0, trailing.Data[3].DeathsAverage)
+
+	centred := series()
+	centred.Rolling(3, true)
+	averages = nil
+	for _, obs := range centred.Data {
+		averages = append(averages, [2]float64{float64(obs.Rolling), obs.CasesAverage})
+	}
+	assert.Equal([][2]float64{{0, 0}, {0, 0}, {3, 4}, {3, 5}, {3, 4}, {0, 0}}, averages)
+
+	buf := new(bytes.Buffer)
+	trailing.Filter(date(22), date(24), false)
+	trailing.Print(buf, "csv")
+	assert.Equal("Date,Cases,Deaths,Recovered,Cases 3d Avg,Deaths 3d Avg\n"+
+		"2021-03-22,109,2,0,,\n"+
+		"2021-03-23,112,3,0,4.0,1.0\n"+
+		"2021-03-24,118,4,0,5.0,1.0\n", buf.String())
+
+	// the averages survive turning the days into the daily change
+	trailing.Daily()
+	assert.Equal(6, trailing.Data[1].Cases)
+	assert.Equal(5.0, trailing.Data[1].CasesAverage)
+
+	// the window is by date, so a missing day leaves out the averages which would start or end on it
+	gaps := series()
+	gaps.Data = append(gaps.Data[:2], gaps.Data[3:]...)
+	gaps.Data = append(gaps.Data, Day{Country: "Australia", Date: date(26), Cases: 127, Deaths: 6})
+	gaps.Rolling(3, false)
+	averages = nil
+	for _, obs := range gaps.Data {
+		averages = append(averages, [2]float64{obs.Date.Sub(date(20)).Hours() / 24, obs.CasesAverage})
+	}
+	assert.Equal([][2]float64{{0, 0}, {1, 0}, {3, 4}, {4, 5}, {5, 0}, {6, 5}}, averages)
+}
+
+func TestRollingLeadIn(t *testing.T) {
+	assert := assert.New(t)
+
+	before, after := RollingLeadIn(7, false)
+	assert.Equal([]int{7, 0}, []int{before, after})
+	before, after = RollingLeadIn(7, true)
+	assert.Equal([]int{4, 3}, []int{before, after})
+	before, after = RollingLeadIn(4, true)
+	assert.Equal([]int{2, 2}, []int{before, after})
+}
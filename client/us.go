@@ -0,0 +1,235 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+//USCountry the country set on the days of the US state and county data
+const USCountry = "USA"
+
+//NYTRecord a single day for a state or county from the New York Times data
+type NYTRecord struct {
+	Date   string `json:"date"`
+	State  string `json:"state"`
+	County string `json:"county"`
+	Fips   string `json:"fips"`
+	Cases  int    `json:"cases"`
+	Deaths int    `json:"deaths"`
+}
+
+//USCountyResponse a single county from the John Hopkins US county data
+type USCountyResponse struct {
+	Province string  `json:"province"`
+	County   string  `json:"county"`
+	RawData  RawData `json:"timeline"`
+}
+
+//USStates queries the New York Times data for US states, or every state when no states are given.
+//A series is returned for each state, any state without data is left out and reported in a SeriesError
+func (c *APIClient) USStates(states []string, from, to time.Time, latest bool) ([]TimeSeries, error) {
+	return c.USStatesContext(context.Background(), states, from, to, latest)
+}
+
+//USStatesContext queries the data for US states like USStates, giving up when ctx is cancelled
+func (c *APIClient) USStatesContext(ctx context.Context, states []string, from, to time.Time, latest bool) ([]TimeSeries, error) {
+	var records []NYTRecord
+	err := c.getJSONContext(ctx, c.lastDaysURL("/nyt/states", from), &records)
+	if err != nil {
+		return nil, err
+	}
+	return groupNYTRecords(records, states, func(r NYTRecord) (string, string) { return r.State, "" }, from, to, latest)
+}
+
+//USCounties queries the John Hopkins data for the counties of a US state, or every county of the state when no counties are given.
+//A series is returned for each county, any county without data is left out and reported in a SeriesError
+func (c *APIClient) USCounties(state string, counties []string, from, to time.Time, latest bool) ([]TimeSeries, error) {
+	return c.USCountiesContext(context.Background(), state, counties, from, to, latest)
+}
+
+//USCountiesContext queries the data for the counties of a US state like USCounties, giving up when ctx is cancelled
+func (c *APIClient) USCountiesContext(ctx context.Context, state string, counties []string, from, to time.Time, latest bool) ([]TimeSeries, error) {
+	var res []USCountyResponse
+	err := c.getJSONContext(ctx, c.lastDaysURL("/historical/usacounties/"+url.PathEscape(strings.ToLower(state)), from), &res)
+	if err != nil {
+		return nil, err
+	}
+
+	var series []TimeSeries
+	failed := SeriesError{}
+	for _, county := range res {
+		if len(counties) > 0 && indexFold(counties, county.County) < 0 {
+			continue
+		}
+		data := APIResponse{Country: USCountry, RawData: county.RawData}
+		err = data.FormatResponse(from, to, latest)
+		if err != nil {
+			failed[county.County] = err
+			if !partialSeries(err) {
+				continue
+			}
+		}
+		for i := range data.TimeSeries.Data {
+			data.TimeSeries.Data[i].Province = county.Province
+			data.TimeSeries.Data[i].County = county.County
+		}
+		series = append(series, data.TimeSeries)
+	}
+	series = orderSeries(series, counties, func(d Day) string { return d.County }, failed)
+	if len(failed) > 0 {
+		return series, failed
+	}
+	return series, nil
+}
+
+//NYTCounties queries the New York Times data for counties of a US state. Without any counties every county
+//of the state is returned, which downloads the data for the whole country.
+//A series is returned for each county, any county without data is left out and reported in a SeriesError
+func (c *APIClient) NYTCounties(state string, counties []string, from, to time.Time, latest bool) ([]TimeSeries, error) {
+	return c.NYTCountiesContext(context.Background(), state, counties, from, to, latest)
+}
+
+//NYTCountiesContext queries the data for counties of a US state like NYTCounties, giving up when ctx is cancelled
+func (c *APIClient) NYTCountiesContext(ctx context.Context, state string, counties []string, from, to time.Time, latest bool) ([]TimeSeries, error) {
+	var records []NYTRecord
+	paths := []string{"/nyt/counties"}
+	if len(counties) > 0 {
+		paths = nil
+		for _, county := range counties {
+			paths = append(paths, "/nyt/counties/"+url.PathEscape(county))
+		}
+	}
+	for _, path := range paths {
+		var res []NYTRecord
+		err := c.getJSONContext(ctx, c.lastDaysURL(path, from), &res)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range res {
+			if strings.EqualFold(r.State, state) {
+				records = append(records, r)
+			}
+		}
+	}
+	return groupNYTRecords(records, counties, func(r NYTRecord) (string, string) { return r.State, r.County }, from, to, latest)
+}
+
+//lastDaysURL the url for path on the base url, with enough days of data to go back to from
+func (c *APIClient) lastDaysURL(path string, from time.Time) string {
+	return fmt.Sprintf("%v%v?lastdays=%v", c.BaseURL, path, calcDays(from))
+}
+
+//groupNYTRecords turns the records into a series for each state or county (as given by key). When names is empty
+//every state or county is returned in alphabetical order, otherwise only names in the given order
+func groupNYTRecords(records []NYTRecord, names []string, key func(NYTRecord) (string, string), from, to time.Time, latest bool) ([]TimeSeries, error) {
+	grouped := map[[2]string]*TimeSeries{}
+	var keys [][2]string
+	for _, r := range records {
+		state, county := key(r)
+		name := state
+		if county != "" {
+			name = county
+		}
+		if len(names) > 0 && indexFold(names, name) < 0 {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", r.Date)
+		if err != nil {
+			return nil, err
+		}
+		k := [2]string{state, county}
+		if _, ok := grouped[k]; !ok {
+			grouped[k] = &TimeSeries{}
+			keys = append(keys, k)
+		}
+		grouped[k].Data = append(grouped[k].Data, Day{
+			Country:  USCountry,
+			Province: state,
+			County:   county,
+			Date:     date,
+			Cases:    r.Cases,
+			Deaths:   r.Deaths,
+		})
+	}
+
+	var series []TimeSeries
+	for _, k := range keys {
+		ts := grouped[k]
+		ts.Order()
+		ts.Filter(from, to, latest)
+		series = append(series, *ts)
+	}
+
+	failed := SeriesError{}
+	series = orderSeries(series, names, func(d Day) string {
+		if d.County != "" {
+			return d.County
+		}
+		return d.Province
+	}, failed)
+	if len(failed) > 0 {
+		return series, failed
+	}
+	return series, nil
+}
+
+//orderSeries sorts the series into the order of names (or alphabetically without any names), recording any name without a series in failed
+func orderSeries(series []TimeSeries, names []string, name func(Day) string, failed SeriesError) []TimeSeries {
+	seriesName := func(ts TimeSeries) string {
+		if len(ts.Data) == 0 {
+			return ""
+		}
+		return name(ts.Data[0])
+	}
+	if len(names) == 0 {
+		sort.SliceStable(series, func(i, j int) bool {
+			return seriesName(series[i]) < seriesName(series[j])
+		})
+		return series
+	}
+
+	var ordered []TimeSeries
+	for _, n := range names {
+		found := false
+		for _, ts := range series {
+			if strings.EqualFold(seriesName(ts), n) {
+				ordered = append(ordered, ts)
+				found = true
+			}
+		}
+		if !found && failed[n] == nil {
+			failed[n] = &notFoundError{"no data found"}
+		}
+	}
+	return ordered
+}
+
+//indexFold the index of name in names ignoring case, or -1
+func indexFold(names []string, name string) int {
+	for i, n := range names {
+		if strings.EqualFold(strings.TrimSpace(n), name) {
+			return i
+		}
+	}
+	return -1
+}
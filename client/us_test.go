@@ -0,0 +1,130 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var nytStatesData = `[
+	{"date":"2021-03-24","state":"Washington","fips":"53","cases":353000,"deaths":5150},
+	{"date":"2021-03-24","state":"New York","fips":"36","cases":1800000,"deaths":49500},
+	{"date":"2021-03-25","state":"Washington","fips":"53","cases":354000,"deaths":5160},
+	{"date":"2021-03-25","state":"New York","fips":"36","cases":1808000,"deaths":49560}
+]`
+
+var nytCountiesData = `[
+	{"date":"2021-03-25","county":"King","state":"Washington","fips":"53033","cases":86000,"deaths":1430},
+	{"date":"2021-03-25","county":"King","state":"Texas","fips":"48269","cases":20,"deaths":0}
+]`
+
+var usCountiesData = `[
+	{"province":"washington","county":"king","timeline":{"cases":{"3/24/21":85900,"3/25/21":86000},"deaths":{"3/24/21":1429,"3/25/21":1430}}},
+	{"province":"washington","county":"pierce","timeline":{"cases":{"3/24/21":40000,"3/25/21":40100},"deaths":{"3/24/21":500,"3/25/21":501}}}
+]`
+
+func usServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/nyt/states":
+			w.Write([]byte(nytStatesData))
+		case "/nyt/counties/King", "/nyt/counties":
+			w.Write([]byte(nytCountiesData))
+		case "/historical/usacounties/washington":
+			w.Write([]byte(usCountiesData))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"State not found"}`))
+		}
+	}))
+}
+
+func TestUSStates(t *testing.T) {
+	assert := assert.New(t)
+	server := usServer()
+	defer server.Close()
+
+	client := NewClient(server.URL + "/historical/%v?lastdays=%v")
+	assert.Equal(server.URL, client.BaseURL)
+	day := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+
+	series, err := client.USStates(nil, day, day, false)
+	assert.NoError(err)
+	assert.Len(series, 2)
+	assert.Equal([]Day{{Country: "USA", Province: "New York", Date: day, Cases: 1808000, Deaths: 49560}}, series[0].Data)
+	assert.Equal([]Day{{Country: "USA", Province: "Washington", Date: day, Cases: 354000, Deaths: 5160}}, series[1].Data)
+
+	series, err = client.USStates([]string{"washington", "oregon"}, day.AddDate(0, 0, -1), day, false)
+	assert.EqualError(err, "oregon: no data found")
+	assert.Len(series, 1)
+	assert.Len(series[0].Data, 2)
+	assert.Equal("Washington", series[0].Data[0].Province)
+}
+
+func TestUSCounties(t *testing.T) {
+	assert := assert.New(t)
+	server := usServer()
+	defer server.Close()
+
+	client := NewClient(server.URL + "/historical/%v?lastdays=%v")
+	day := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+
+	series, err := client.USCounties("Washington", nil, day, day, false)
+	assert.NoError(err)
+	assert.Len(series, 2)
+	assert.Equal([]Day{{Country: "USA", Province: "washington", County: "king", Date: day, Cases: 86000, Deaths: 1430}}, series[0].Data)
+	assert.Equal("pierce", series[1].Data[0].County)
+
+	series, err = client.USCounties("washington", []string{"pierce", "king"}, day, day, false)
+	assert.NoError(err)
+	assert.Equal("pierce", series[0].Data[0].County)
+	assert.Equal("king", series[1].Data[0].County)
+
+	_, err = client.USCounties("nowhere", nil, day, day, false)
+	assert.EqualError(err, "State not found")
+}
+
+func TestNYTCounties(t *testing.T) {
+	assert := assert.New(t)
+	server := usServer()
+	defer server.Close()
+
+	client := NewClient(server.URL + "/historical/%v?lastdays=%v")
+	day := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+
+	for _, counties := range [][]string{{"King"}, nil} {
+		series, err := client.NYTCounties("washington", counties, day, day, false)
+		assert.NoError(err)
+		assert.Equal([]TimeSeries{{Data: []Day{{Country: "USA", Province: "Washington", County: "King", Date: day, Cases: 86000, Deaths: 1430}}}}, series)
+	}
+
+	series, err := client.NYTCounties("oregon", []string{"King"}, day, day, false)
+	assert.EqualError(err, "King: no data found")
+	assert.Empty(series)
+}
+
+func TestNewClientBaseURL(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(DefaultBaseURL, NewClient("http://localhost:8080/%v%v").BaseURL)
+	assert.Equal("http://localhost:8080/v3/covid-19", NewClient("http://localhost:8080/v3/covid-19/historical/%v?lastdays=%v").BaseURL)
+}
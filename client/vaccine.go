@@ -0,0 +1,68 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+//VaccineResponse the vaccine coverage response from the server
+type VaccineResponse struct {
+	Country  string         `json:"country"`
+	Timeline map[string]int `json:"timeline"`
+}
+
+//Vaccines queries the server for the total vaccine doses given in a country, or the world for the reserved global names
+func (c *APIClient) Vaccines(country string, from, to time.Time, latest bool) (TimeSeries, error) {
+	return c.VaccinesContext(context.Background(), country, from, to, latest)
+}
+
+//VaccinesContext queries the server for the total vaccine doses like Vaccines, giving up when ctx is cancelled
+func (c *APIClient) VaccinesContext(ctx context.Context, country string, from, to time.Time, latest bool) (TimeSeries, error) {
+	var data VaccineResponse
+	var ts TimeSeries
+	var err error
+	if IsGlobal(country) {
+		// the whole world is returned as a bare timeline
+		data.Country = "Global"
+		err = c.getJSONContext(ctx, c.lastDaysURL("/vaccine/coverage", from), &data.Timeline)
+	} else {
+		err = c.getJSONContext(ctx, c.lastDaysURL("/vaccine/coverage/countries/"+url.PathEscape(country), from), &data)
+	}
+	if err != nil {
+		return ts, err
+	}
+	var badDates []string
+	for date, doses := range data.Timeline {
+		formattedTime, err := parseDate(date)
+		if err != nil {
+			badDates = append(badDates, date)
+			continue
+		}
+		ts.Data = append(ts.Data, Day{
+			Country: data.Country,
+			Date:    formattedTime,
+			Doses:   doses,
+		})
+	}
+	days := len(ts.Data)
+	ts.Order()
+	ts.Filter(from, to, latest)
+	return ts, badDatesError(badDates, days)
+}
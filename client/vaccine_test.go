@@ -0,0 +1,68 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVaccines(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/vaccine/coverage/countries/australia":
+			w.Write([]byte(`{"country":"Australia","timeline":{"3/23/21":138000,"3/24/21":158000,"3/25/21":196000}}`))
+		case "/vaccine/coverage":
+			w.Write([]byte(`{"3/24/21":400000000,"3/25/21":410000000}`))
+		case "/vaccine/coverage/countries/baddate":
+			w.Write([]byte(`{"country":"Baddate","timeline":{"2021-3-25":1}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"No vaccine data for requested country or country does not exist"}`))
+		}
+	}))
+	defer server.Close()
+
+	var source VaccineSource = NewClient(server.URL + "/historical/%v?lastdays=%v")
+	from := time.Date(2021, 3, 24, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+
+	ts, err := source.VaccinesContext(context.Background(), "australia", from, to, false)
+	assert.NoError(err)
+	assert.Equal([]Day{
+		{Country: "Australia", Date: from, Doses: 158000},
+		{Country: "Australia", Date: to, Doses: 196000},
+	}, ts.Data)
+
+	ts, err = source.VaccinesContext(context.Background(), "global", from, to, true)
+	assert.NoError(err)
+	assert.Equal([]Day{{Country: "Global", Date: to, Doses: 410000000}}, ts.Data)
+
+	_, err = source.VaccinesContext(context.Background(), "azzz", from, to, false)
+	assert.EqualError(err, "No vaccine data for requested country or country does not exist")
+
+	_, err = source.VaccinesContext(context.Background(), "baddate", from, to, false)
+	assert.Error(err)
+}
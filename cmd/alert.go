@@ -0,0 +1,148 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/johnDorian/clatest/alert"
+	"github.com/johnDorian/clatest/client"
+	"github.com/spf13/cobra"
+)
+
+var rulesPath, statePath string
+
+// alertCmd checks the alert rules
+var alertCmd = &cobra.Command{
+	Use:   "alert",
+	Short: "Check the alert rules and notify the ones which fire",
+	Long: `Check the rules in the --rules yaml file against the latest data, posting each
+rule which fires to its webhook and/or running its command. A rule only notifies
+once until it stops firing, which is remembered in the --state file. Run it from
+cron (or a systemd timer) to keep checking.
+	`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		source, err := newSource(sourceName, RequestURI, dataPath)
+		if err == nil {
+			ctx, stop := interruptContext()
+			defer stop()
+			notifier := alert.NewNotifier(&http.Client{Timeout: timeout})
+			err = run_alert(ctx, source, rulesPath, statePath, notifier.Notify, cmd.OutOrStdout())
+		}
+		exitOnError(err)
+	},
+}
+
+func init() {
+	alertCmd.Flags().StringVar(&rulesPath, "rules", "", "The yaml file of alert rules")
+	alertCmd.Flags().StringVar(&statePath, "state", "", "Where to remember the rules which fired (default the user's config directory)")
+	alertCmd.MarkFlagRequired("rules")
+	rootCmd.AddCommand(alertCmd)
+}
+
+//run_alert checks the rules against the days they need up to today and prints the alerts which were notified.
+//The state is saved even when some of the rules fail, so the ones which notified don't notify again
+func run_alert(ctx context.Context, source client.DataSource, rulesPath, statePath string, notify func(context.Context, alert.Alert) error, output io.Writer) error {
+	rules, err := alert.LoadRules(rulesPath)
+	if err != nil {
+		return err
+	}
+	if statePath == "" {
+		statePath, err = alert.DefaultStatePath()
+		if err != nil {
+			return err
+		}
+	}
+	state, err := alert.LoadState(statePath)
+	if err != nil {
+		return err
+	}
+
+	series, err := ruleSeries(ctx, source, rules)
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: some countries couldn't be fetched\n%v\n", err)
+	}
+	alerts, checkErr := alert.Check(ctx, rules, series, state, notify)
+	for _, a := range alerts {
+		fmt.Fprintln(output, a)
+	}
+	err = state.Save(statePath)
+	if err != nil {
+		return err
+	}
+	return checkErr
+}
+
+//ruleSeries fetches the countries of the rules, with a couple of days more than the rules need in case the
+//latest day isn't out yet. The series are by the country as the rules have it
+func ruleSeries(ctx context.Context, source client.DataSource, rules []alert.Rule) (map[string]client.TimeSeries, error) {
+	days := 0
+	var names []string
+	seen := map[string]bool{}
+	for _, rule := range rules {
+		if rule.Days() > days {
+			days = rule.Days()
+		}
+		if !seen[rule.Country] {
+			names = append(names, rule.Country)
+			seen[rule.Country] = true
+		}
+	}
+
+	failed := client.SeriesError{}
+	var countries, ruleNames []string
+	for _, name := range names {
+		resolved, resolveErr := resolveCountries(source, []string{name})
+		if len(resolveErr) > 0 {
+			failed[name] = resolveErr[name]
+			continue
+		}
+		countries = append(countries, resolved[0])
+		ruleNames = append(ruleNames, name)
+	}
+	series := map[string]client.TimeSeries{}
+	if len(countries) == 0 {
+		return series, mergeErrors(failed, nil)
+	}
+
+	to := today()
+	fetched, err := client.FetchAllContext(ctx, source, countries, to.AddDate(0, 0, -days-2), to, false, workers)
+	err = warnBadDates(err)
+	var fetchErr client.SeriesError
+	if err != nil && !errors.As(err, &fetchErr) {
+		return series, err
+	}
+	i := 0
+	for j, country := range countries {
+		if countryErr, ok := fetchErr[country]; ok {
+			failed[ruleNames[j]] = countryErr
+			continue
+		}
+		series[ruleNames[j]] = fetched[i]
+		i++
+	}
+	return series, mergeErrors(failed, nil)
+}
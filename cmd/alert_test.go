@@ -0,0 +1,84 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/johnDorian/clatest/alert"
+	"github.com/johnDorian/clatest/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunAlert(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "clatest-alert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	rulesPath := filepath.Join(dir, "rules.yaml")
+	statePath := filepath.Join(dir, "state.json")
+	ioutil.WriteFile(rulesPath, []byte(`
+rules:
+  - name: australia-cases
+    country: Australia
+    metric: new_cases
+    above: 10
+    command: "true"
+  - name: australia-deaths
+    country: Australia
+    metric: deaths
+    above: 100
+    command: "true"
+  - name: atlantis-cases
+    country: Atlantis
+    metric: cases
+    above: 1
+    command: "true"
+`), 0644)
+
+	source := stubSource{
+		days: map[string][]client.Day{"Australia": dailyCases("Australia", today(), 1000, 1012)},
+		errs: map[string]error{"Atlantis": client.ErrCountryNotFound},
+	}
+	var notified []string
+	notify := func(ctx context.Context, a alert.Alert) error {
+		notified = append(notified, a.Rule.Name)
+		return nil
+	}
+
+	buf := new(bytes.Buffer)
+	err = run_alert(context.Background(), source, rulesPath, statePath, notify, buf)
+	assert.EqualError(err, "atlantis-cases: no data for Atlantis")
+	assert.Equal([]string{"australia-cases"}, notified)
+	assert.Equal("australia-cases: new_cases of Australia is 12 (above 10) on "+today().Format("2006-01-02")+"\n", buf.String())
+
+	// already fired
+	buf.Reset()
+	run_alert(context.Background(), source, rulesPath, statePath, notify, buf)
+	assert.Equal([]string{"australia-cases"}, notified)
+	assert.Empty(buf.String())
+
+	assert.Error(run_alert(context.Background(), source, filepath.Join(dir, "missing.yaml"), statePath, notify, buf))
+}
@@ -0,0 +1,113 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/johnDorian/clatest/client"
+	"github.com/spf13/cobra"
+)
+
+var noCache = false
+var cacheTTL = client.DefaultCacheTTL
+
+// cacheCmd manages the responses kept on disk
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the downloaded responses kept on disk",
+	Long: `Responses from disease.sh (and the owid download) are kept in the user's cache
+directory, so running clatest again within --cache-ttl doesn't download them again.
+Older responses are revalidated with the server before they are used.
+	`,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every cached response",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cache, err := newCache()
+		if err == nil {
+			err = run_cache_clear(cache, cmd.OutOrStdout())
+		}
+		exitOnError(err)
+	},
+}
+
+var cacheInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show where the cache is and how much is in it",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cache, err := newCache()
+		if err == nil {
+			err = writeOutput(func(output io.Writer) error {
+				return run_cache_info(cache, format, output)
+			})
+		}
+		exitOnError(err)
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd, cacheInfoCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Always download the data rather than using the cache")
+	rootCmd.PersistentFlags().DurationVar(&cacheTTL, "cache-ttl", cacheTTL, "How long a cached response is used before checking with the server again")
+}
+
+//newCache the cache in the user's cache directory
+func newCache() (*client.Cache, error) {
+	dir, err := client.DefaultCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return client.NewCache(dir, cacheTTL, nil), nil
+}
+
+//useCache sends the requests of httpClient through the cache, unless --no-cache is set or there is no cache directory
+func useCache(httpClient *http.Client) {
+	if noCache {
+		return
+	}
+	cache, err := newCache()
+	if err != nil {
+		return
+	}
+	cache.Wrap(httpClient)
+}
+
+func run_cache_clear(cache *client.Cache, output io.Writer) error {
+	err := cache.Clear()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(output, "Cleared %v\n", cache.Dir)
+	return nil
+}
+
+func run_cache_info(cache *client.Cache, format string, output io.Writer) error {
+	info, err := cache.Info()
+	if err != nil {
+		return err
+	}
+	info.Print(output, format)
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johnDorian/clatest/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCache(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "clatest-cache")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(responseData))
+	}))
+	defer server.Close()
+
+	cache := client.NewCache(filepath.Join(dir, "clatest"), time.Hour, nil)
+	c := client.NewClient(server.URL + "/historical/%v?lastdays=%v")
+	cache.Wrap(c.Client)
+	err = run_cmd(context.Background(), c, []string{"australia"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", new(bytes.Buffer))
+	assert.NoError(err)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(run_cache_info(cache, "csv", buf))
+	assert.Contains(buf.String(), "Directory,Entries,Bytes,Oldest,Newest\n"+cache.Dir+",1,")
+
+	buf = new(bytes.Buffer)
+	assert.NoError(run_cache_clear(cache, buf))
+	assert.Equal("Cleared "+cache.Dir+"\n", buf.String())
+
+	info, err := cache.Info()
+	assert.NoError(err)
+	assert.Equal(0, info.Entries)
+}
+
+func TestNewClientNoCache(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { noCache = false }()
+
+	noCache = true
+	_, cached := newClient(RequestURI).Client.Transport.(*client.Cache)
+	assert.False(cached)
+
+	noCache = false
+	_, cached = newClient(RequestURI).Client.Transport.(*client.Cache)
+	assert.True(cached)
+}
+
+func TestNewClientRetries(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { retries = client.DefaultRetries }()
+
+	retries = 0
+	assert.Equal(0, newClient(RequestURI).Retry.Retries)
+}
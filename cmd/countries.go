@@ -0,0 +1,67 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/johnDorian/clatest/client"
+	"github.com/spf13/cobra"
+)
+
+// countriesCmd lists the countries of the data source
+var countriesCmd = &cobra.Command{
+	Use:   "countries",
+	Short: "List the countries the data source has data for",
+	Long: `List every country the data source (see --source) has data for, with its ISO
+codes, its continent and whether the provinces (or states) of the country have their
+own data. With disease.sh countries can be queried by their name or either of the
+ISO codes. The other sources only know their own names: jhu its country names and
+owid its location names or ISO3 codes.
+	`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := interruptContext()
+		defer stop()
+		source, err := newSource(sourceName, RequestURI, dataPath)
+		if err == nil {
+			err = writeOutput(func(output io.Writer) error {
+				return run_countries(ctx, source, format, output)
+			})
+		}
+		exitOnError(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(countriesCmd)
+}
+
+func run_countries(ctx context.Context, source client.DataSource, format string, output io.Writer) error {
+	countrySource, ok := source.(client.CountrySource)
+	if !ok {
+		return fmt.Errorf("the data source can't list its countries")
+	}
+	countries, err := countrySource.CountriesContext(ctx)
+	if err != nil {
+		return err
+	}
+	countries.Print(output, format)
+	return nil
+}
@@ -0,0 +1,49 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/johnDorian/clatest/client"
+	"github.com/stretchr/testify/assert"
+)
+
+//listlessSource a data source which can't list its countries
+type listlessSource struct{}
+
+func (listlessSource) SeriesContext(ctx context.Context, country string, from, to time.Time, latest bool) (client.TimeSeries, error) {
+	return client.TimeSeries{}, nil
+}
+
+func TestRunCountries(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := new(bytes.Buffer)
+	err := run_countries(context.Background(), client.NewJHUSource("../client/testdata/jhu"), "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Country,ISO2,ISO3,Continent,Provinces\nAustralia,AU,AUS,Australia-Oceania,yes\nNew Zealand,NZ,NZL,Australia-Oceania,no\n", buf.String())
+
+	err = run_countries(context.Background(), client.NewJHUSource("../client/testdata/missing"), "csv", new(bytes.Buffer))
+	assert.Error(err)
+
+	err = run_countries(context.Background(), listlessSource{}, "csv", new(bytes.Buffer))
+	assert.EqualError(err, "the data source can't list its countries")
+}
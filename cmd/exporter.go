@@ -0,0 +1,226 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johnDorian/clatest/client"
+	"github.com/spf13/cobra"
+)
+
+var exporterCountries []string
+var interval = 15 * time.Minute
+
+//averageDays the days averaged by covid_new_cases_7d_avg
+const averageDays = 7
+
+// exporterCmd serves the latest numbers as Prometheus metrics
+var exporterCmd = &cobra.Command{
+	Use:   "exporter",
+	Short: "Serve the latest numbers of some countries as Prometheus metrics",
+	Long: `Refresh the latest numbers of the --countries every --interval and serve them on
+/metrics in the Prometheus text format, for example
+
+  covid_cumulative_cases{country="Australia"} 29239
+
+The refresh goes through the cache, so an interval shorter than --cache-ttl only
+asks disease.sh for the responses which have expired.
+	`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		source, err := newSource(sourceName, RequestURI, dataPath)
+		if err == nil {
+			ctx, stop := interruptContext()
+			defer stop()
+			err = run_exporter(ctx, source, splitNames(exporterCountries), interval, addr)
+		}
+		exitOnError(err)
+	},
+}
+
+func init() {
+	exporterCmd.Flags().StringSliceVar(&exporterCountries, "countries", nil, "Countries to export, comma separated")
+	exporterCmd.Flags().DurationVar(&interval, "interval", interval, "How often to refresh the numbers")
+	exporterCmd.Flags().StringVar(&addr, "addr", addr, "Address to listen on")
+	exporterCmd.MarkFlagRequired("countries")
+	rootCmd.AddCommand(exporterCmd)
+}
+
+//exportedCountry the last numbers of a country and how its refreshes went
+type exportedCountry struct {
+	day         client.Day // The latest day, the zero Day until the first successful refresh
+	average     float64
+	hasAverage  bool
+	lastSuccess time.Time
+	errors      int
+}
+
+//exporter keeps the latest numbers of the countries to serve as metrics
+type exporter struct {
+	source    client.DataSource
+	countries []string
+	mu        sync.Mutex
+	exported  map[string]*exportedCountry
+}
+
+func newExporter(source client.DataSource, countries []string) *exporter {
+	e := &exporter{source: source, countries: countries, exported: map[string]*exportedCountry{}}
+	for _, country := range countries {
+		e.exported[country] = &exportedCountry{}
+	}
+	return e
+}
+
+//refresh fetches the days needed for the average up to now. A country which fails keeps its last numbers
+//and counts an error, so a stale country shows up in the last success time rather than as a gap
+func (e *exporter) refresh(ctx context.Context, now time.Time) error {
+	to := now.UTC().Truncate(24 * time.Hour)
+	from := to.AddDate(0, 0, -averageDays-1)
+	series, err := client.FetchAllContext(ctx, e.source, e.countries, from, to, false, workers)
+	err = warnBadDates(err)
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	var failed client.SeriesError
+	if err != nil && !errors.As(err, &failed) {
+		// the whole refresh failed, e.g. the batch request
+		failed = client.SeriesError{}
+		for _, country := range e.countries {
+			failed[country] = err
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	i := 0
+	for _, country := range e.countries {
+		exported := e.exported[country]
+		if _, ok := failed[country]; ok {
+			exported.errors++
+			continue
+		}
+		ts := series[i]
+		i++
+		if len(ts.Data) == 0 {
+			exported.errors++
+			continue
+		}
+		exported.day = ts.Data[len(ts.Data)-1]
+		exported.average, exported.hasAverage = ts.NewCasesAverage(averageDays)
+		exported.lastSuccess = now
+	}
+	return err
+}
+
+//metric a single gauge or counter with one value per country
+type metric struct {
+	name  string
+	kind  string
+	help  string
+	value func(*exportedCountry) (float64, bool)
+}
+
+var metrics = []metric{
+	{"covid_cumulative_cases", "gauge", "Cumulative confirmed cases on the latest day.", func(c *exportedCountry) (float64, bool) {
+		return float64(c.day.Cases), !c.lastSuccess.IsZero()
+	}},
+	{"covid_cumulative_deaths", "gauge", "Cumulative deaths on the latest day.", func(c *exportedCountry) (float64, bool) {
+		return float64(c.day.Deaths), !c.lastSuccess.IsZero()
+	}},
+	{"covid_new_cases_7d_avg", "gauge", "Average daily new cases over the last 7 days.", func(c *exportedCountry) (float64, bool) {
+		return c.average, c.hasAverage
+	}},
+	{"covid_exporter_last_success_timestamp_seconds", "gauge", "Unix time the country was last refreshed successfully.", func(c *exportedCountry) (float64, bool) {
+		return float64(c.lastSuccess.UnixNano()) / 1e9, !c.lastSuccess.IsZero()
+	}},
+	{"covid_exporter_upstream_errors_total", "counter", "Refreshes of the country which failed.", func(c *exportedCountry) (float64, bool) {
+		return float64(c.errors), true
+	}},
+}
+
+//writeMetrics writes the metrics in the Prometheus text format
+func (e *exporter) writeMetrics(output io.Writer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, m := range metrics {
+		fmt.Fprintf(output, "# HELP %v %v\n# TYPE %v %v\n", m.name, m.help, m.name, m.kind)
+		for _, country := range e.countries {
+			if value, ok := m.value(e.exported[country]); ok {
+				fmt.Fprintf(output, "%v{country=\"%v\"} %v\n", m.name, escapeLabel(country), strconv.FormatFloat(value, 'f', -1, 64))
+			}
+		}
+	}
+}
+
+//escapeLabel escapes a label value for the Prometheus text format
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+//run_exporter refreshes the countries every interval and serves /metrics on addr until ctx is cancelled
+func run_exporter(ctx context.Context, source client.DataSource, countries []string, interval time.Duration, addr string) error {
+	if len(countries) == 0 {
+		return fmt.Errorf("no countries to export, see --countries")
+	}
+	if interval <= 0 {
+		return fmt.Errorf("the interval must be more than 0")
+	}
+	countries, failed := resolveCountries(source, countries)
+	if len(failed) > 0 {
+		return failed
+	}
+	e := newExporter(source, countries)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		e.writeMetrics(w)
+	})
+	server := &http.Server{Addr: addr, Handler: mux}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+	fmt.Fprintf(os.Stderr, "Serving metrics on http://%v/metrics\n", addr)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := e.refresh(ctx, time.Now()); err != nil && !errors.Is(err, context.Canceled) {
+			fmt.Fprintf(os.Stderr, "warning: refresh failed\n%v\n", err)
+		}
+		select {
+		case err := <-errs:
+			return err
+		case <-ctx.Done():
+			shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			return server.Shutdown(shutdown)
+		case <-ticker.C:
+		}
+	}
+}
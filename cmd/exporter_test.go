@@ -0,0 +1,131 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/johnDorian/clatest/client"
+	"github.com/stretchr/testify/assert"
+)
+
+//stubSource returns the days of each country between from and to, or the error of the country
+type stubSource struct {
+	days map[string][]client.Day
+	errs map[string]error
+}
+
+func (s stubSource) SeriesContext(ctx context.Context, country string, from, to time.Time, latest bool) (client.TimeSeries, error) {
+	if err := s.errs[country]; err != nil {
+		return client.TimeSeries{}, err
+	}
+	ts := client.TimeSeries{Data: append([]client.Day(nil), s.days[country]...)}
+	ts.Filter(from, to, latest)
+	return ts, nil
+}
+
+//dailyCases the days up to to with the cumulative cases, one day per value
+func dailyCases(country string, to time.Time, cases ...int) []client.Day {
+	var days []client.Day
+	for i, c := range cases {
+		days = append(days, client.Day{Country: country, Date: to.AddDate(0, 0, i-len(cases)+1), Cases: c, Deaths: c / 100})
+	}
+	return days
+}
+
+func TestExporter(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2021, 3, 25, 6, 0, 0, 0, time.UTC)
+	today := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+	source := stubSource{
+		days: map[string][]client.Day{
+			"Australia":   dailyCases("Australia", today, 1000, 1010, 1020, 1030, 1040, 1050, 1060, 1070, 1080),
+			"New Zealand": dailyCases("New Zealand", today, 200, 210),
+		},
+		errs: map[string]error{"Atlantis": client.ErrCountryNotFound},
+	}
+	e := newExporter(source, []string{"Australia", "New Zealand", "Atlantis"})
+	err := e.refresh(context.Background(), now)
+	assert.Error(err)
+
+	buf := new(bytes.Buffer)
+	e.writeMetrics(buf)
+	assert.Equal(`# HELP covid_cumulative_cases Cumulative confirmed cases on the latest day.
+# TYPE covid_cumulative_cases gauge
+covid_cumulative_cases{country="Australia"} 1080
+covid_cumulative_cases{country="New Zealand"} 210
+# HELP covid_cumulative_deaths Cumulative deaths on the latest day.
+# TYPE covid_cumulative_deaths gauge
+covid_cumulative_deaths{country="Australia"} 10
+covid_cumulative_deaths{country="New Zealand"} 2
+# HELP covid_new_cases_7d_avg Average daily new cases over the last 7 days.
+# TYPE covid_new_cases_7d_avg gauge
+covid_new_cases_7d_avg{country="Australia"} 10
+# HELP covid_exporter_last_success_timestamp_seconds Unix time the country was last refreshed successfully.
+# TYPE covid_exporter_last_success_timestamp_seconds gauge
+covid_exporter_last_success_timestamp_seconds{country="Australia"} 1616652000
+covid_exporter_last_success_timestamp_seconds{country="New Zealand"} 1616652000
+# HELP covid_exporter_upstream_errors_total Refreshes of the country which failed.
+# TYPE covid_exporter_upstream_errors_total counter
+covid_exporter_upstream_errors_total{country="Australia"} 0
+covid_exporter_upstream_errors_total{country="New Zealand"} 0
+covid_exporter_upstream_errors_total{country="Atlantis"} 1
+`, buf.String())
+
+	// a failed refresh keeps the last numbers
+	source.errs["Australia"] = fmt.Errorf("boom")
+	e.refresh(context.Background(), now.Add(time.Hour))
+	buf.Reset()
+	e.writeMetrics(buf)
+	assert.Contains(buf.String(), `covid_cumulative_cases{country="Australia"} 1080`)
+	assert.Contains(buf.String(), `covid_exporter_last_success_timestamp_seconds{country="Australia"} 1616652000`)
+	assert.Contains(buf.String(), `covid_exporter_last_success_timestamp_seconds{country="New Zealand"} 1616655600`)
+	assert.Contains(buf.String(), `covid_exporter_upstream_errors_total{country="Australia"} 1`)
+}
+
+func TestExporterCountries(t *testing.T) {
+	assert := assert.New(t)
+
+	// the flag has already split the countries, so a name which isn't in the country table is still on its own
+	exporterCmd.Flags().Set("countries", "australia, narnia")
+	defer func() { exporterCountries = nil }()
+	assert.Equal([]string{"australia", "narnia"}, splitNames(exporterCountries))
+	assert.Equal([]string{"usa", "kosovo", "new zealand"}, splitNames([]string{"usa", "kosovo", "new zealand"}))
+}
+
+func TestEscapeLabel(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(`Korea, South`, escapeLabel("Korea, South"))
+	assert.Equal(`a\"b\\c\nd`, escapeLabel("a\"b\\c\nd"))
+}
+
+func TestRunExporter(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	source := stubSource{}
+	assert.EqualError(run_exporter(ctx, source, nil, time.Minute, "127.0.0.1:0"), "no countries to export, see --countries")
+	assert.EqualError(run_exporter(ctx, source, []string{"Australia"}, 0, "127.0.0.1:0"), "the interval must be more than 0")
+	assert.NoError(run_exporter(ctx, source, []string{"Australia"}, time.Minute, "127.0.0.1:0"))
+}
@@ -0,0 +1,68 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/johnDorian/clatest/client"
+	"github.com/spf13/cobra"
+)
+
+// provincesCmd lists the provinces which can be passed to --province
+var provincesCmd = &cobra.Command{
+	Use:   "provinces <country>",
+	Short: "List the provinces (or states) of a country",
+	Long: `List the provinces (or states) of a country which have their own data. Any of
+these can be passed to the --province flag.
+	`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := interruptContext()
+		defer stop()
+		source, err := newSource(sourceName, RequestURI, dataPath)
+		if err == nil {
+			err = writeOutput(func(output io.Writer) error {
+				return run_provinces(ctx, source, strings.Join(args, " "), format, output)
+			})
+		}
+		exitOnError(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(provincesCmd)
+}
+
+func run_provinces(ctx context.Context, source client.DataSource, country, format string, output io.Writer) error {
+	provinceSource, ok := source.(client.ProvinceSource)
+	if !ok {
+		return fmt.Errorf("the data source doesn't have province data")
+	}
+	countries, failed := resolveCountries(source, []string{country})
+	if len(failed) > 0 {
+		return failed
+	}
+	provinces, err := provinceSource.ProvincesContext(ctx, countries[0])
+	if err != nil {
+		return err
+	}
+	provinces.Print(output, format)
+	return nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/johnDorian/clatest/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunProvinces(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		source      client.DataSource
+		country     string
+		expected    string
+		expectError bool
+	}{
+		{
+			source:   client.NewJHUSource("../client/testdata/jhu"),
+			country:  "australia",
+			expected: "Province\nAustralian Capital Territory\nNew South Wales\nVictoria\n",
+		},
+		{
+			source:      client.NewJHUSource("../client/testdata/missing"),
+			country:     "australia",
+			expectError: true,
+		},
+		{
+			source:      client.NewOWIDSource("../client/testdata/owid/owid-covid-data.csv"),
+			country:     "australia",
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		buf := new(bytes.Buffer)
+		err := run_provinces(context.Background(), test.source, test.country, "csv", buf)
+		if test.expectError {
+			assert.Error(err)
+			continue
+		}
+		assert.NoError(err)
+		assert.Equal(test.expected, buf.String())
+	}
+}
@@ -16,9 +16,12 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"strings"
 	"time"
 
@@ -26,9 +29,20 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var from, to, exact, format, outFile string
+var from, to, exact, format, outFile, sourceName, dataPath string
 var latest = false
+var mode = "cumulative"
+var rolling = 0
+var centred = false
+var per = ""
+var workers = 4
+var retries = client.DefaultRetries
+var timeout = client.DefaultTimeout
+
+var province []string
+var continent []string
 var RequestURI = "https://disease.sh/v3/covid-19/historical/%v?lastdays=%v"
+var OWIDURI = "https://covid.ourworldindata.org/data/owid-covid-data.csv"
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -39,28 +53,33 @@ statistics. The data is downloaded from disease.sh and is sourced from John
 Hopkins.
 	`,
 	Version: "v0.0.2",
-	Args:    cobra.MinimumNArgs(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(continent) > 0 {
+			return nil
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := interruptContext()
+		defer stop()
+		var source client.DataSource
 		var err error
-		switch {
-		case outFile != "":
-			f, err := os.Create(outFile)
-			if err != nil {
-				fmt.Println(err)
-				os.Exit(1)
-			}
-			defer f.Close()
-			err = run_cmd(strings.Join(args[:], " "), RequestURI, from, to, exact, format, f)
-		default:
-			err = run_cmd(strings.Join(args[:], " "), RequestURI, from, to, exact, format, os.Stdout)
+		if watch > 0 {
+			source, err = newWatchSource(sourceName, RequestURI, dataPath)
+		} else {
+			source, err = newSource(sourceName, RequestURI, dataPath)
 		}
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+		if err == nil {
+			err = writeOutput(func(output io.Writer) error {
+				if watch > 0 {
+					return run_watch(ctx, source, parseCountries(args), from, to, exact, format, watch, output)
+				}
+				return run_cmd(ctx, source, parseCountries(args), from, to, exact, format, output)
+			})
 		}
-
+		exitOnError(err)
 	},
 }
 
@@ -83,33 +102,457 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&exact, "on", "o", "", "A single date to get")
 	rootCmd.PersistentFlags().StringVar(&format, "format", "markdown", "Output format (markdown, csv)")
 	rootCmd.PersistentFlags().StringVar(&outFile, "file", "", "file path and name (if desired)")
+	rootCmd.PersistentFlags().StringVar(&sourceName, "source", "disease.sh", "Data source to query (disease.sh, jhu, owid)")
+	rootCmd.Flags().StringSliceVar(&province, "province", nil, "Provinces (or states) of the country to get, comma separated")
+	rootCmd.Flags().StringSliceVar(&continent, "continent", nil, "Continents to total up from their countries, comma separated")
+	rootCmd.Flags().BoolVar(&joinVaccines, "vaccines", false, "Add the total vaccine doses as an extra column")
+	rootCmd.Flags().StringVar(&mode, "mode", mode, "Show the cumulative totals or the daily change (cumulative, daily)")
+	rootCmd.Flags().IntVar(&rolling, "rolling", 0, "Add the N day averages of the daily new cases and deaths")
+	rootCmd.Flags().BoolVar(&centred, "centred", false, "Centre the --rolling averages on each day rather than ending them on it")
+	rootCmd.Flags().StringVar(&per, "per", "", fmt.Sprintf("Add the cases and deaths per population and the 14 day notification rate (100k, 1m). "+
+		"The populations are from disease.sh, otherwise the built in table (%v)", client.PopulationVersion))
+	rootCmd.PersistentFlags().IntVar(&workers, "workers", workers, "Maximum number of countries to fetch at the same time")
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", retries, "How many times to try a request again after a 429, 5xx, timeout or dropped connection")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", timeout, "The longest a request can take including retries, 0 for no limit")
+	rootCmd.PersistentFlags().StringVar(&dataPath, "path", "", "Directory, file or url read by the jhu and owid data sources")
 
 }
 
-func run_cmd(country, RequestURI, from, to, exact string, format string, output io.Writer) error {
-	client := client.NewClient(RequestURI)
+//writeOutput runs fn with either the --file or stdout as the output
+func writeOutput(fn func(io.Writer) error) error {
+	if outFile == "" {
+		return fn(os.Stdout)
+	}
+	f, err := os.Create(outFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return fn(f)
+}
 
-	fromDate, err := time.Parse("2006-01-02", from)
+//The exit codes of the errors a script might want to handle differently
+const (
+	exitError       = 1   // Any other error, including bad arguments
+	exitNotFound    = 2   // A country (or province, state or continent) wasn't found
+	exitRateLimited = 3   // The server asked for fewer requests, try again later
+	exitUpstream    = 4   // The server failed to answer
+	exitInterrupted = 130 // Ctrl-C
+)
+
+//exitOnError prints the error and exits. Errors go to stderr so the output of any countries which succeeded stays clean
+func exitOnError(err error) {
+	if err == nil {
+		return
+	}
+	if errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, "interrupted")
+	} else {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(exitCode(err))
+}
+
+//exitCode the exit code for err. When several countries failed for different reasons the first of
+//interrupted, rate limited, not found and upstream failure wins
+func exitCode(err error) int {
+	var upstream *client.UpstreamError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	case errors.Is(err, client.ErrRateLimited):
+		return exitRateLimited
+	case errors.Is(err, client.ErrCountryNotFound):
+		return exitNotFound
+	case errors.As(err, &upstream):
+		return exitUpstream
+	default:
+		return exitError
+	}
+}
+
+//interruptContext a context which is cancelled by the first Ctrl-C, after that a second Ctrl-C exits straight away
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
+//newSource returns the data source registered under name
+func newSource(name, RequestURI, path string) (client.DataSource, error) {
+	switch name {
+	case "disease.sh":
+		return newClient(RequestURI), nil
+	case "jhu":
+		if path == "" {
+			return nil, fmt.Errorf("the jhu source requires --path to the csv directory")
+		}
+		return client.NewJHUSource(path), nil
+	case "owid":
+		if path == "" {
+			path = OWIDURI
+		}
+		source := client.NewOWIDSource(path)
+		useCache(source.Client)
+		return source, nil
+	default:
+		return nil, fmt.Errorf("unknown data source: %v", name)
+	}
+}
+
+//newClient a disease.sh client using the cache and the --retries and --timeout flags
+func newClient(RequestURI string) *client.APIClient {
+	c := client.NewClient(RequestURI)
+	c.Retry.Retries = retries
+	c.Client.Timeout = timeout
+	useCache(c.Client)
+	return c
+}
+
+//parseCountries splits the arguments into a list of countries. Countries are either separated by commas
+//or quoted as separate arguments, otherwise all the arguments make up a single country (e.g. united states)
+//splitNames the names in args, each of which is a name of its own or several separated by commas. Unlike
+//parseCountries the words of an argument are never split up or joined with the next, so it is used for the
+//states and counties (as a state can't be told apart from the words of another state) and for the names of a
+//flag, which are already separate
+func splitNames(args []string) []string {
+	var names []string
+	for _, arg := range args {
+		for _, name := range strings.Split(arg, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+func parseCountries(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	joined := strings.Join(args, " ")
+	quoted := false
+	for _, arg := range args {
+		quoted = quoted || strings.Contains(arg, " ")
+	}
+
+	var countries []string
+	switch {
+	case strings.Contains(joined, ","):
+		for _, country := range strings.Split(joined, ",") {
+			if country = strings.TrimSpace(country); country != "" {
+				countries = append(countries, country)
+			}
+		}
+	case quoted:
+		for _, country := range args {
+			countries = append(countries, strings.TrimSpace(country))
+		}
+	case separateCountries(args):
+		countries = args
+	default:
+		countries = []string{joined}
+	}
+	return countries
+}
+
+//separateCountries whether the arguments are several countries (e.g. australia germany) rather than the
+//words of a single country (e.g. united states)
+func separateCountries(args []string) bool {
+	resolver, err := client.NewResolver()
+	if err != nil || len(args) < 2 {
+		return false
+	}
+	if _, ok := resolver.Lookup(strings.Join(args, " ")); ok {
+		return false
+	}
+	for _, arg := range args {
+		if _, ok := resolver.Lookup(arg); !ok && !client.IsGlobal(arg) {
+			return false
+		}
+	}
+	return true
+}
+
+//resolveCountries replaces the countries in the country table with the names disease.sh uses, correcting
+//small misspellings. Other sources have their own names so are left alone. A name close to more than one
+//country is left out and reported in a SeriesError suggesting the countries, a name which isn't close to
+//any country is passed on as is, as the server may still know it
+func resolveCountries(source client.DataSource, countries []string) ([]string, client.SeriesError) {
+	failed := client.SeriesError{}
+	if _, ok := source.(*client.APIClient); !ok {
+		return countries, failed
+	}
+	resolver, err := client.NewResolver()
 	if err != nil {
+		return countries, failed
+	}
+	var resolved []string
+	for _, country := range countries {
+		if client.IsGlobal(country) {
+			resolved = append(resolved, country)
+			continue
+		}
+		match, err := resolver.Resolve(country)
+		var unknown *client.UnknownCountryError
+		switch {
+		case err == nil:
+			resolved = append(resolved, match.Name)
+		case errors.As(err, &unknown) && len(unknown.Suggestions) > 0:
+			failed[country] = err
+		default:
+			resolved = append(resolved, country)
+		}
+	}
+	return resolved, failed
+}
+
+//mergeErrors adds the countries which failed to err. When err isn't about single countries (e.g. a batch
+//request failed) both are kept in a combinedError
+func mergeErrors(failed client.SeriesError, err error) error {
+	if len(failed) == 0 {
 		return err
 	}
+	if err == nil {
+		return failed
+	}
+	other, ok := err.(client.SeriesError)
+	if !ok {
+		return &combinedError{err: err, failed: failed}
+	}
+	for country, countryErr := range other {
+		failed[country] = countryErr
+	}
+	return failed
+}
+
+//combinedError an error about all the countries along with the countries which failed before they were fetched
+type combinedError struct {
+	err    error
+	failed client.SeriesError
+}
+
+func (e *combinedError) Error() string {
+	return e.err.Error() + "\n" + e.failed.Error()
+}
+
+//Is whether either error matches target
+func (e *combinedError) Is(target error) bool {
+	return errors.Is(e.err, target) || errors.Is(e.failed, target)
+}
+
+//As finds the first error which matches target, looking at the error about all the countries first
+func (e *combinedError) As(target interface{}) bool {
+	return errors.As(e.err, target) || errors.As(e.failed, target)
+}
+
+//warnBadDates prints a warning on stderr for the countries whose timeline had some keys which aren't dates, as
+//the rest of their days are still there, and returns the error of the countries which failed
+func warnBadDates(err error) error {
+	warnings, err := client.SplitBadDates(err)
+	if len(warnings) > 0 {
+		fmt.Fprintf(os.Stderr, "warning: some days were left out\n%v\n", warnings)
+	}
+	return err
+}
+
+//parseDates parses the from, to and on flags, where on replaces both from and to
+func parseDates(from, to, exact string) (time.Time, time.Time, error) {
+	fromDate, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return fromDate, fromDate, err
+	}
 	toDate, err := time.Parse("2006-01-02", to)
 	if err != nil {
-		return err
+		return fromDate, toDate, err
 	}
 	if exact != "" {
 		exactDate, err := time.Parse("2006-01-02", exact)
 		if err != nil {
-			return err
+			return fromDate, toDate, err
 		}
 		fromDate = exactDate
 		toDate = exactDate
 	}
+	return fromDate, toDate, nil
+}
 
-	res, err := client.Get(country, fromDate, toDate, latest)
+func run_cmd(ctx context.Context, source client.DataSource, countries []string, from, to, exact string, format string, output io.Writer) error {
+	fromDate, toDate, err := parseDates(from, to, exact)
 	if err != nil {
 		return err
 	}
-	res.TimeSeries.Print(output, format)
-	return nil
+	series, err := fetchSeries(ctx, source, countries, fromDate, toDate)
+	if len(series) > 0 {
+		res := client.Combine(series...)
+		res.ShowCountry = manyPlaces(countries)
+		res.Print(output, format)
+	}
+	return err
+}
+
+//manyPlaces whether more than one country or continent was asked for. Their output always has the Country
+//column, so its layout doesn't depend on which of them failed
+func manyPlaces(countries []string) bool {
+	return len(countries)+len(continent) > 1
+}
+
+//fetchSeries fetches the countries, provinces and continents of the flags. Any countries which failed are
+//returned in a SeriesError along with the series of the rest. Once ctx is cancelled ctx.Err() is returned
+func fetchSeries(ctx context.Context, source client.DataSource, countries []string, fromDate, toDate time.Time) ([]client.TimeSeries, error) {
+	if mode != "cumulative" && mode != "daily" {
+		return nil, fmt.Errorf("unknown mode: %v", mode)
+	}
+	if rolling < 0 {
+		return nil, fmt.Errorf("--rolling must be a number of days")
+	}
+	perPeople, ok := map[string]int{"": 0, "100k": 100000, "1m": 1000000}[per]
+	if !ok {
+		return nil, fmt.Errorf("unknown --per: %v", per)
+	}
+	if joinVaccines && len(province) > 0 {
+		return nil, fmt.Errorf("vaccine doses are only available for whole countries")
+	}
+	if len(continent) > 0 && len(province) > 0 {
+		return nil, fmt.Errorf("provinces can't be combined with continents")
+	}
+
+	// the change on the first day needs the day before, and the averages the days around them
+	fetchFrom, fetchTo := fromDate, toDate
+	if mode == "daily" {
+		fetchFrom = fromDate.AddDate(0, 0, -1)
+	}
+	if rolling > 0 {
+		before, after := client.RollingLeadIn(rolling, centred)
+		fetchFrom, fetchTo = fromDate.AddDate(0, 0, -before), toDate.AddDate(0, 0, after)
+	}
+	if lead := fromDate.AddDate(0, 0, -14); perPeople > 0 && lead.Before(fetchFrom) {
+		fetchFrom = lead
+	}
+	countries, failed := resolveCountries(source, countries)
+	var series []client.TimeSeries
+	var err error
+	if len(province) > 0 {
+		provinceSource, ok := source.(client.ProvinceSource)
+		if !ok {
+			return nil, fmt.Errorf("the data source doesn't have province data")
+		}
+		if len(failed) > 0 {
+			return nil, failed
+		}
+		if len(countries) != 1 {
+			return nil, fmt.Errorf("provinces can only be queried for a single country")
+		}
+		series, err = provinceSource.ProvinceSeriesContext(ctx, countries[0], province, fetchFrom, fetchTo, latest)
+	} else if len(countries) > 0 {
+		series, err = client.FetchAllContext(ctx, source, countries, fetchFrom, fetchTo, latest, workers)
+	}
+	err = warnBadDates(mergeErrors(failed, err))
+	if errors.Is(err, context.Canceled) {
+		return nil, err
+	}
+	if joinVaccines {
+		vaccineErr := addVaccines(ctx, source, series)
+		if vaccineErr != nil {
+			return nil, vaccineErr
+		}
+	}
+	if len(continent) > 0 {
+		continents, continentErr := continentSeries(ctx, source, continent, fetchFrom, fetchTo)
+		if continentErr != nil {
+			return nil, continentErr
+		}
+		series = append(series, continents...)
+	}
+	var populations map[string]int
+	if perPeople > 0 {
+		var popErr error
+		populations, popErr = seriesPopulations(ctx, source, series)
+		if popErr != nil {
+			return nil, popErr
+		}
+	}
+	if mode == "daily" || rolling > 0 || perPeople > 0 {
+		for i := range series {
+			// the averages and the rate are the change of the cumulative values
+			series[i].Rolling(rolling, centred)
+			if perPeople > 0 {
+				series[i].NotificationRate(populations)
+			}
+			if mode == "daily" {
+				series[i].Daily()
+			}
+			if perPeople > 0 {
+				series[i].PerCapita(populations, perPeople)
+			}
+			series[i].Filter(fromDate, toDate, latest)
+		}
+	}
+	return series, err
+}
+
+//seriesPopulations the population of the country (or continent or world) of each series, from the source if it
+//knows them and otherwise from the embedded table. A country without a population is an error rather than
+//quietly leaving out its per population values. Provinces and counties are always left without
+func seriesPopulations(ctx context.Context, source client.DataSource, series []client.TimeSeries) (map[string]int, error) {
+	var countries []string
+	seen := map[string]bool{}
+	for _, ts := range series {
+		for _, obs := range ts.Data {
+			if obs.Province == "" && obs.County == "" && !seen[obs.Country] {
+				countries = append(countries, obs.Country)
+				seen[obs.Country] = true
+			}
+		}
+	}
+	var populations map[string]int
+	var err error
+	if populationSource, ok := source.(client.PopulationSource); ok {
+		populations, err = populationSource.PopulationsContext(ctx, countries)
+	} else {
+		populations, err = client.EmbeddedPopulations(countries)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, country := range countries {
+		if populations[country] == 0 {
+			return nil, fmt.Errorf("no population for %v, so it can't be shown --per people", country)
+		}
+	}
+	return populations, nil
+}
+
+//continentSeries totals up each of the continents from their countries. Countries without any data are
+//reported as a warning on stderr, as a continent is still worth showing without its smallest members
+func continentSeries(ctx context.Context, source client.DataSource, names []string, from, to time.Time) ([]client.TimeSeries, error) {
+	continentSource, ok := source.(client.ContinentSource)
+	if !ok {
+		return nil, fmt.Errorf("the data source doesn't have continent data")
+	}
+	var series []client.TimeSeries
+	for _, name := range names {
+		members, err := continentSource.ContinentContext(ctx, strings.TrimSpace(name))
+		if errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", name, err)
+		}
+		ts, err := client.ContinentSeriesContext(ctx, source, members, from, to, latest, workers)
+		if errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v is missing some countries\n%v\n", members.Continent, err)
+		}
+		series = append(series, ts)
+	}
+	return series, nil
 }
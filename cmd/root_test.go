@@ -17,12 +17,16 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/johnDorian/clatest/client"
 	"github.com/stretchr/testify/assert"
@@ -147,9 +151,425 @@ func TestRunCMD(t *testing.T) {
 
 	for _, test := range tests {
 		buf := new(bytes.Buffer)
-		run_cmd(test.country, server.URL+"/%v%v", test.from, test.to, test.exact, test.format, buf)
+		c := client.NewClient(server.URL + "/%v%v")
+		c.Retry.Base = time.Millisecond
+		run_cmd(context.Background(), c, []string{test.country}, test.from, test.to, test.exact, test.format, buf)
 		assert.Equal(test.expected, buf.String())
 
 	}
 
 }
+
+func TestNewSource(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		name        string
+		path        string
+		expectError bool
+	}{
+		{name: "disease.sh", expectError: false},
+		{name: "jhu", path: "../client/testdata/jhu", expectError: false},
+		{name: "jhu", expectError: true},
+		{name: "owid", expectError: false},
+		{name: "owid", path: "../client/testdata/owid/owid-covid-data.csv", expectError: false},
+		{name: "nope", expectError: true},
+	}
+	for _, test := range tests {
+		source, err := newSource(test.name, "http://localhost:8080/%v%v", test.path)
+		if test.expectError {
+			assert.Error(err)
+			continue
+		}
+		assert.NoError(err)
+		assert.Implements((*client.DataSource)(nil), source)
+	}
+}
+
+func TestRunCMDMultipleCountries(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var items []string
+		for _, country := range strings.Split(strings.Trim(r.URL.Path, "/0123456789"), ",") {
+			if !strings.Contains(strings.ToLower(country), "australia") {
+				items = append(items, `{"message":"country not found"}`)
+				continue
+			}
+			items = append(items, strings.Replace(responseData, "Australia", country, 1))
+		}
+		switch {
+		case len(items) > 1:
+			w.Write([]byte("[" + strings.Join(items, ",") + "]"))
+		case strings.Contains(items[0], "message"):
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(items[0]))
+		default:
+			w.Write([]byte(items[0]))
+		}
+	}))
+	defer server.Close()
+
+	buf := new(bytes.Buffer)
+	err := run_cmd(context.Background(), client.NewClient(server.URL+"/%v%v"), []string{"australia", "west australia"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Country,Date,Cases,Deaths,Recovered\nAustralia,2021-03-25,29239,909,22991\nwest australia,2021-03-25,29239,909,22991\n", buf.String())
+
+	buf = new(bytes.Buffer)
+	err = run_cmd(context.Background(), client.NewClient(server.URL+"/%v%v"), []string{"australia", "global"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+	assert.EqualError(err, "global: country not found")
+	assert.Equal("Country,Date,Cases,Deaths,Recovered\nAustralia,2021-03-25,29239,909,22991\n", buf.String())
+
+	// the Country column is there for the countries asked for, not just the ones which succeeded
+	buf = new(bytes.Buffer)
+	err = run_cmd(context.Background(), client.NewClient(server.URL+"/%v%v"), []string{"australia", "azzz"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+	assert.EqualError(err, "azzz: country not found")
+	assert.Equal("Country,Date,Cases,Deaths,Recovered\nAustralia,2021-03-25,29239,909,22991\n", buf.String())
+
+	buf = new(bytes.Buffer)
+	err = run_cmd(context.Background(), client.NewClient(server.URL+"/%v%v"), []string{"australia"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Date,Cases,Deaths,Recovered\n2021-03-25,29239,909,22991\n", buf.String())
+}
+
+func TestParseCountries(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		in       []string
+		expected []string
+	}{
+		{in: []string{"australia"}, expected: []string{"australia"}},
+		{in: []string{"united", "states"}, expected: []string{"united states"}},
+		{in: []string{"australia", "new zealand", "germany"}, expected: []string{"australia", "new zealand", "germany"}},
+		{in: []string{"australia,", "new", "zealand,germany"}, expected: []string{"australia", "new zealand", "germany"}},
+		{in: []string{"australia,,"}, expected: []string{"australia"}},
+		{in: []string{"australia", "germany", "nzl"}, expected: []string{"australia", "germany", "nzl"}},
+		{in: []string{"south", "korea"}, expected: []string{"south korea"}},
+		{in: []string{"new", "zealand"}, expected: []string{"new zealand"}},
+	}
+	for _, test := range tests {
+		assert.Equal(test.expected, parseCountries(test.in))
+	}
+}
+
+func TestRunCMDProvinces(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { province = nil }()
+	source := client.NewJHUSource("../client/testdata/jhu")
+
+	province = []string{"victoria", "new south wales"}
+	buf := new(bytes.Buffer)
+	err := run_cmd(context.Background(), source, []string{"australia"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Province,Date,Cases,Deaths,Recovered\nVictoria,2021-03-25,20483,820,19563\nNew South Wales,2021-03-25,5111,54,3109\n", buf.String())
+
+	err = run_cmd(context.Background(), source, []string{"australia", "new zealand"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", new(bytes.Buffer))
+	assert.Error(err)
+
+	err = run_cmd(context.Background(), client.NewOWIDSource("../client/testdata/owid/owid-covid-data.csv"), []string{"australia"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", new(bytes.Buffer))
+	assert.Error(err)
+}
+
+func TestRunCMDDaily(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { mode, province = "cumulative", nil }()
+	source := client.NewJHUSource("../client/testdata/jhu")
+
+	mode = "daily"
+	buf := new(bytes.Buffer)
+	err := run_cmd(context.Background(), source, []string{"new zealand"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Date,Cases,Deaths,Recovered\n2021-03-25,4,0,0\n", buf.String())
+
+	// the first day of the data has no day before
+	buf.Reset()
+	err = run_cmd(context.Background(), source, []string{"new zealand"}, "2021-03-23", "2021-03-25", "", "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Date,Cases,Deaths,Recovered\n2021-03-24,3,0,0\n2021-03-25,4,0,0\n", buf.String())
+
+	province = []string{"victoria"}
+	buf.Reset()
+	err = run_cmd(context.Background(), source, []string{"australia"}, "2021-03-24", "2021-03-25", "", "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Province,Date,Cases,Deaths,Recovered\nVictoria,2021-03-24,1,0,1\nVictoria,2021-03-25,2,0,2\n", buf.String())
+
+	mode = "weekly"
+	err = run_cmd(context.Background(), source, []string{"new zealand"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", new(bytes.Buffer))
+	assert.EqualError(err, "unknown mode: weekly")
+}
+
+func TestRunCMDRolling(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { mode, rolling, centred = "cumulative", 0, false }()
+	to := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+	source := stubSource{days: map[string][]client.Day{
+		"Australia": dailyCases("Australia", to, 1000, 1010, 1030, 1060, 1100, 1150, 1210),
+	}}
+
+	rolling = 3
+	buf := new(bytes.Buffer)
+	err := run_cmd(context.Background(), source, []string{"Australia"}, "2021-03-23", "2021-03-24", "", "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Date,Cases,Deaths,Recovered,Cases 3d Avg,Deaths 3d Avg\n"+
+		"2021-03-23,1100,11,0,30.0,0.3\n"+
+		"2021-03-24,1150,11,0,40.0,0.3\n", buf.String())
+
+	mode, centred = "daily", true
+	buf.Reset()
+	err = run_cmd(context.Background(), source, []string{"Australia"}, "2021-03-23", "2021-03-25", "", "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Date,Cases,Deaths,Recovered,Cases 3d Avg,Deaths 3d Avg\n"+
+		"2021-03-23,40,1,0,40.0,0.3\n"+
+		"2021-03-24,50,0,0,50.0,0.7\n"+
+		"2021-03-25,60,1,0,,\n", buf.String())
+
+	rolling = -1
+	err = run_cmd(context.Background(), source, []string{"Australia"}, "2021-03-23", "2021-03-25", "", "csv", new(bytes.Buffer))
+	assert.EqualError(err, "--rolling must be a number of days")
+}
+
+func TestRunCMDPer(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { per, mode = "", "cumulative" }()
+	to := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+	var cases []int
+	for day := 0; day <= 15; day++ {
+		cases = append(cases, 29000+day*100)
+	}
+	source := stubSource{days: map[string][]client.Day{"Australia": dailyCases("Australia", to, cases...)}}
+
+	// the population of Australia in the embedded table is 25499884
+	per = "100k"
+	buf := new(bytes.Buffer)
+	err := run_cmd(context.Background(), source, []string{"Australia"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Date,Cases,Deaths,Recovered,Cases per 100k,Deaths per 100k,14d Rate per 100k\n"+
+		"2021-03-25,30500,305,0,119.61,1.20,5.5\n", buf.String())
+
+	per, mode = "1m", "daily"
+	buf.Reset()
+	err = run_cmd(context.Background(), source, []string{"Australia"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Date,Cases,Deaths,Recovered,Cases per 1m,Deaths per 1m,14d Rate per 100k\n"+
+		"2021-03-25,100,1,0,3.92,0.04,5.5\n", buf.String())
+
+	// the world is the sum of its countries, and a place without a population is an error
+	per, mode = "100k", "cumulative"
+	source.days["Global"] = dailyCases("Global", to, cases...)
+	source.days["Diamond Princess"] = dailyCases("Diamond Princess", to, cases...)
+	buf.Reset()
+	err = run_cmd(context.Background(), source, []string{"Global"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+	assert.NoError(err)
+	assert.Contains(buf.String(), "Cases per 100k")
+	err = run_cmd(context.Background(), source, []string{"Diamond Princess"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", new(bytes.Buffer))
+	assert.EqualError(err, "no population for Diamond Princess, so it can't be shown --per people")
+
+	// owid calls the world World
+	buf.Reset()
+	err = run_cmd(context.Background(), client.NewOWIDSource("../client/testdata/owid/owid-covid-data.csv"), []string{"global"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+	assert.NoError(err)
+	assert.Contains(buf.String(), "Cases per 100k")
+
+	per = "10k"
+	err = run_cmd(context.Background(), source, []string{"Australia"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", new(bytes.Buffer))
+	assert.EqualError(err, "unknown --per: 10k")
+}
+
+func TestParseDates(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		from         string
+		to           string
+		exact        string
+		expectedFrom time.Time
+		expectedTo   time.Time
+		expectError  bool
+	}{
+		{from: "2021-01-01", to: "2021-01-02", expectedFrom: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), expectedTo: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{from: "2021-01-01", to: "2021-01-02", exact: "2021-03-25", expectedFrom: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC), expectedTo: time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)},
+		{from: "01/01/2021", to: "2021-01-02", expectError: true},
+		{from: "2021-01-01", to: "", expectError: true},
+		{from: "2021-01-01", to: "2021-01-02", exact: "today", expectError: true},
+	}
+	for _, test := range tests {
+		fromDate, toDate, err := parseDates(test.from, test.to, test.exact)
+		if test.expectError {
+			assert.Error(err)
+			continue
+		}
+		assert.NoError(err)
+		assert.Equal(test.expectedFrom, fromDate)
+		assert.Equal(test.expectedTo, toDate)
+	}
+}
+
+func TestRunCMDContinent(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { continent = nil }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/continents/oceania" {
+			w.Write([]byte(`{"continent":"Australia-Oceania","countries":["Australia","New Zealand"]}`))
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/continents/") {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Continent not found or doesn't have any cases"}`))
+			return
+		}
+		var items []string
+		for _, country := range strings.Split(strings.TrimPrefix(r.URL.Path, "/historical/"), ",") {
+			items = append(items, strings.Replace(responseData, "Australia", country, 1))
+		}
+		if len(items) == 1 {
+			w.Write([]byte(items[0]))
+			return
+		}
+		w.Write([]byte("[" + strings.Join(items, ",") + "]"))
+	}))
+	defer server.Close()
+	source := client.NewClient(server.URL + "/historical/%v?lastdays=%v")
+
+	continent = []string{"oceania"}
+	buf := new(bytes.Buffer)
+	err := run_cmd(context.Background(), source, nil, "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Date,Cases,Deaths,Recovered\n2021-03-25,58478,1818,45982\n", buf.String())
+
+	buf = new(bytes.Buffer)
+	err = run_cmd(context.Background(), source, []string{"fiji"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Country,Date,Cases,Deaths,Recovered\nFiji,2021-03-25,29239,909,22991\nAustralia-Oceania,2021-03-25,58478,1818,45982\n", buf.String())
+
+	continent = []string{"atlantis"}
+	err = run_cmd(context.Background(), source, nil, "2021-01-01", "2021-01-01", "2021-03-25", "csv", new(bytes.Buffer))
+	assert.EqualError(err, "atlantis: Continent not found or doesn't have any cases")
+	assert.Equal(exitNotFound, exitCode(err))
+
+	err = run_cmd(context.Background(), client.NewJHUSource("../client/testdata/jhu"), nil, "2021-01-01", "2021-01-01", "2021-03-25", "csv", new(bytes.Buffer))
+	assert.EqualError(err, "the data source doesn't have continent data")
+}
+
+func TestRunCMDCancelled(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	buf := new(bytes.Buffer)
+	err := run_cmd(ctx, client.NewJHUSource("../client/testdata/jhu"), []string{"australia", "new zealand"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+	assert.Equal(context.Canceled, err)
+	assert.Empty(buf.String())
+
+	// every command sends its requests with ctx
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+	c := client.NewClient(server.URL + "/historical/%v?lastdays=%v")
+	defer func() { province, continent = nil, nil }()
+	runs := map[string]func() error{
+		"snapshot": func() error { return run_snapshot(ctx, c, []string{"australia"}, "csv", buf) },
+		"vaccines": func() error {
+			return run_vaccines(ctx, c, []string{"australia", "fiji"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+		},
+		"us-states": func() error {
+			return run_us_states(ctx, c, []string{"texas"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+		},
+		"us-counties": func() error {
+			return run_us_counties(ctx, c, "washington", nil, "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+		},
+		"provinces": func() error { return run_provinces(ctx, c, "australia", "csv", buf) },
+		"countries": func() error { return run_countries(ctx, c, "csv", buf) },
+		"--province": func() error {
+			province = []string{"victoria"}
+			defer func() { province = nil }()
+			return run_cmd(ctx, c, []string{"australia"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+		},
+		"--continent": func() error {
+			continent = []string{"oceania"}
+			defer func() { continent = nil }()
+			return run_cmd(ctx, c, nil, "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+		},
+	}
+	for name, run := range runs {
+		err = run()
+		assert.True(errors.Is(err, context.Canceled), name)
+		assert.Equal(exitInterrupted, exitCode(err), name)
+	}
+	assert.Equal(0, requests)
+	assert.Empty(buf.String())
+}
+
+func TestNewClientTimeout(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { timeout = client.DefaultTimeout }()
+
+	assert.Equal(client.DefaultTimeout, newClient(RequestURI).Client.Timeout)
+	timeout = 0
+	assert.Equal(time.Duration(0), newClient(RequestURI).Client.Timeout)
+}
+
+func TestExitCode(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		err      error
+		expected int
+	}{
+		{err: nil, expected: 0},
+		{err: errors.New("bad date"), expected: exitError},
+		{err: context.Canceled, expected: exitInterrupted},
+		{err: fmt.Errorf("fetching: %w", context.Canceled), expected: exitInterrupted},
+		{err: &client.UpstreamError{StatusCode: http.StatusNotFound}, expected: exitNotFound},
+		{err: &client.UpstreamError{StatusCode: http.StatusTooManyRequests}, expected: exitRateLimited},
+		{err: &client.UpstreamError{StatusCode: http.StatusBadGateway}, expected: exitUpstream},
+		{err: client.SeriesError{"azzz": client.ErrCountryNotFound, "australia": &client.UpstreamError{StatusCode: http.StatusBadGateway}}, expected: exitNotFound},
+	}
+	for _, test := range tests {
+		assert.Equal(test.expected, exitCode(test.err))
+	}
+
+	// a missing province is not found too
+	province = []string{"tasmania"}
+	defer func() { province = nil }()
+	err := run_cmd(context.Background(), client.NewJHUSource("../client/testdata/jhu"), []string{"australia"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", new(bytes.Buffer))
+	assert.EqualError(err, "tasmania: country not found: province australia/tasmania")
+	assert.Equal(exitNotFound, exitCode(err))
+}
+
+func TestResolveCountries(t *testing.T) {
+	assert := assert.New(t)
+	source := client.NewClient(RequestURI)
+
+	countries, failed := resolveCountries(source, []string{"united states", "austrailia", "global", "diamond princess", "chda"})
+	assert.Equal([]string{"USA", "Australia", "global", "diamond princess"}, countries)
+	assert.EqualError(failed, `chda: unknown country "chda", did you mean Chad, China or Cuba?`)
+
+	// other sources have their own names
+	countries, failed = resolveCountries(client.NewJHUSource("../client/testdata/jhu"), []string{"united states"})
+	assert.Equal([]string{"united states"}, countries)
+	assert.Empty(failed)
+}
+
+func TestWarnBadDates(t *testing.T) {
+	assert := assert.New(t)
+	assert.NoError(warnBadDates(nil))
+	assert.NoError(warnBadDates(client.SeriesError{"a": &client.BadDatesError{Keys: []string{"soon"}}}))
+	assert.EqualError(warnBadDates(client.SeriesError{"a": &client.BadDatesError{Keys: []string{"soon"}}, "b": client.ErrCountryNotFound}), "b: country not found")
+	assert.EqualError(warnBadDates(errors.New("offline")), "offline")
+}
+
+func TestMergeErrors(t *testing.T) {
+	assert := assert.New(t)
+	assert.NoError(mergeErrors(client.SeriesError{}, nil))
+	assert.EqualError(mergeErrors(client.SeriesError{"a": errors.New("typo")}, nil), "a: typo")
+	assert.EqualError(mergeErrors(client.SeriesError{}, errors.New("offline")), "offline")
+	combined := mergeErrors(client.SeriesError{"a": client.ErrCountryNotFound}, &client.UpstreamError{StatusCode: http.StatusBadGateway, Message: "offline"})
+	assert.EqualError(combined, "offline\na: country not found")
+	assert.True(errors.Is(combined, client.ErrCountryNotFound))
+	var upstream *client.UpstreamError
+	assert.True(errors.As(combined, &upstream))
+	assert.Equal(exitNotFound, exitCode(combined))
+	assert.EqualError(mergeErrors(client.SeriesError{"a": errors.New("typo")}, client.SeriesError{"b": errors.New("missing")}), "a: typo\nb: missing")
+}
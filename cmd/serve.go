@@ -0,0 +1,180 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/johnDorian/clatest/client"
+	"github.com/spf13/cobra"
+)
+
+var addr = "localhost:8080"
+
+// serveCmd serves the time series over http
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the time series as a local JSON/CSV http api",
+	Long: `Serve the time series of the data source (see --source) over http, so several
+dashboards can share one cache of the upstream data rather than each querying it.
+
+  GET /series/{country}?from=2021-03-01&to=2021-03-25&format=json
+
+The country may be several comma separated countries. The query takes from, to,
+on and latest like the flags of clatest, and format is one of json (the default),
+csv or markdown.
+	`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		source, err := newSource(sourceName, RequestURI, dataPath)
+		if err == nil {
+			ctx, stop := interruptContext()
+			defer stop()
+			err = run_serve(ctx, source, addr)
+		}
+		exitOnError(err)
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&addr, "addr", addr, "Address to listen on")
+	rootCmd.AddCommand(serveCmd)
+}
+
+//run_serve serves the api on addr until ctx is cancelled, then lets the requests in flight finish
+func run_serve(ctx context.Context, source client.DataSource, addr string) error {
+	server := &http.Server{Addr: addr, Handler: newServeMux(source)}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+	fmt.Fprintf(os.Stderr, "Serving on http://%v\n", addr)
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return server.Shutdown(shutdown)
+}
+
+//newServeMux the routes of the api
+func newServeMux(source client.DataSource) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/series/", func(w http.ResponseWriter, r *http.Request) {
+		serveSeries(source, w, r)
+	})
+	return mux
+}
+
+//serveSeries answers /series/{country} with the time series of the countries. Any country which fails
+//fails the whole request, so a client never mistakes a partial answer for a complete one
+func serveSeries(source client.DataSource, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeHTTPError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %v not allowed", r.Method))
+		return
+	}
+	query := r.URL.Query()
+	countries := parseCountries([]string{strings.TrimPrefix(r.URL.Path, "/series/")})
+	if len(countries) == 0 || strings.TrimSpace(countries[0]) == "" {
+		writeHTTPError(w, http.StatusNotFound, fmt.Errorf("no country given, use /series/{country}"))
+		return
+	}
+	format := query.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" && format != "markdown" {
+		writeHTTPError(w, http.StatusBadRequest, fmt.Errorf("unknown format: %v", format))
+		return
+	}
+	fromDate, toDate, err := parseDates(
+		queryDefault(query.Get("from"), time.Now().AddDate(0, 0, -1).Format("2006-01-02")),
+		queryDefault(query.Get("to"), time.Now().Format("2006-01-02")),
+		query.Get("on"),
+	)
+	if err != nil {
+		writeHTTPError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	countries, failed := resolveCountries(source, countries)
+	var series []client.TimeSeries
+	if len(countries) > 0 {
+		series, err = client.FetchAllContext(r.Context(), source, countries, fromDate, toDate, query.Get("latest") == "true", workers)
+	}
+	err = warnBadDates(mergeErrors(failed, err))
+	if err != nil {
+		writeHTTPError(w, httpStatus(err), err)
+		return
+	}
+
+	res := client.Combine(series...)
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		res.Print(w, format)
+	case "markdown":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		res.Print(w, format)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		res.WriteJSON(w)
+	}
+}
+
+//queryDefault the query value, or def when it is empty
+func queryDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+//httpStatus the status of the api for err, following the same order as exitCode
+func httpStatus(err error) int {
+	var upstream *client.UpstreamError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, client.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, client.ErrCountryNotFound):
+		return http.StatusNotFound
+	case errors.As(err, &upstream):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+//writeHTTPError writes err as a json object with an error message
+func writeHTTPError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,129 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/johnDorian/clatest/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeSeries(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(newServeMux(client.NewJHUSource("../client/testdata/jhu")))
+	defer server.Close()
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+		expected    string
+	}{
+		{
+			path:        "/series/New%20Zealand?from=2021-03-24&to=2021-03-25",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			expected:    `[{"country":"New Zealand","date":"2021-03-24","cases":2478,"deaths":26,"recovered":0},{"country":"New Zealand","date":"2021-03-25","cases":2482,"deaths":26,"recovered":0}]` + "\n",
+		},
+		{
+			path:        "/series/new zealand?on=2021-03-25&format=csv",
+			status:      http.StatusOK,
+			contentType: "text/csv; charset=utf-8",
+			expected:    "Date,Cases,Deaths,Recovered\n2021-03-25,2482,26,0\n",
+		},
+		{
+			path:        "/series/australia,new zealand?on=2021-03-25&format=csv",
+			status:      http.StatusOK,
+			contentType: "text/csv; charset=utf-8",
+			expected:    "Country,Date,Cases,Deaths,Recovered\nAustralia,2021-03-25,25718,877,22791\nNew Zealand,2021-03-25,2482,26,0\n",
+		},
+		{
+			path:        "/series/new zealand?latest=true&format=csv",
+			status:      http.StatusOK,
+			contentType: "text/csv; charset=utf-8",
+			expected:    "Date,Cases,Deaths,Recovered\n2021-03-25,2482,26,0\n",
+		},
+		{
+			path:        "/series/atlantis?on=2021-03-25",
+			status:      http.StatusNotFound,
+			contentType: "application/json",
+			expected:    `{"error":"atlantis: country not found: atlantis"}` + "\n",
+		},
+		{
+			path:        "/series/new zealand?from=25/03/2021",
+			status:      http.StatusBadRequest,
+			contentType: "application/json",
+		},
+		{
+			path:        "/series/new zealand?format=xml",
+			status:      http.StatusBadRequest,
+			contentType: "application/json",
+			expected:    `{"error":"unknown format: xml"}` + "\n",
+		},
+		{
+			path:        "/series/",
+			status:      http.StatusNotFound,
+			contentType: "application/json",
+			expected:    `{"error":"no country given, use /series/{country}"}` + "\n",
+		},
+	}
+
+	for _, test := range tests {
+		resp, err := http.Get(server.URL + test.path)
+		if !assert.NoError(err) {
+			continue
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(test.status, resp.StatusCode, test.path)
+		assert.Equal(test.contentType, resp.Header.Get("Content-Type"), test.path)
+		if test.expected != "" {
+			assert.Equal(test.expected, string(body), test.path)
+		}
+	}
+
+	resp, err := http.Post(server.URL+"/series/new zealand", "text/plain", nil)
+	if assert.NoError(err) {
+		resp.Body.Close()
+		assert.Equal(http.StatusMethodNotAllowed, resp.StatusCode)
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(http.StatusNotFound, httpStatus(client.SeriesError{"atlantis": client.ErrCountryNotFound}))
+	assert.Equal(http.StatusTooManyRequests, httpStatus(&client.UpstreamError{StatusCode: 429}))
+	assert.Equal(http.StatusBadGateway, httpStatus(&client.UpstreamError{StatusCode: 502}))
+	assert.Equal(http.StatusServiceUnavailable, httpStatus(context.Canceled))
+	assert.Equal(http.StatusInternalServerError, httpStatus(fmt.Errorf("boom")))
+}
+
+func TestRunServe(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(run_serve(ctx, client.NewJHUSource("../client/testdata/jhu"), "127.0.0.1:0"))
+}
@@ -0,0 +1,56 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"io"
+
+	"github.com/johnDorian/clatest/client"
+	"github.com/spf13/cobra"
+)
+
+// snapshotCmd gets the current numbers for a country
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot <country>",
+	Short: "Get the current numbers for your country",
+	Long: `Get the current numbers for one or more countries, including today's cases and
+deaths, active and critical cases, tests, population and the per million figures.
+The data is downloaded from disease.sh.
+	`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := interruptContext()
+		defer stop()
+		err := writeOutput(func(output io.Writer) error {
+			return run_snapshot(ctx, newClient(RequestURI), parseCountries(args), format, output)
+		})
+		exitOnError(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+}
+
+func run_snapshot(ctx context.Context, c *client.APIClient, countries []string, format string, output io.Writer) error {
+	countries, failed := resolveCountries(c, countries)
+	snapshots, err := c.SnapshotsContext(ctx, countries)
+	if len(snapshots) > 0 {
+		snapshots.Print(output, format)
+	}
+	return mergeErrors(failed, err)
+}
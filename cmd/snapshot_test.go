@@ -0,0 +1,54 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/johnDorian/clatest/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunSnapshot(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.EqualFold(r.URL.Path, "/countries/australia") {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Country not found or doesn't have any cases"}`))
+			return
+		}
+		w.Write([]byte(`{"updated":1616716800000,"country":"Australia","cases":29239,"todayCases":9,"deaths":909,"todayDeaths":0,"active":2020,"critical":1,"tests":14985005,"population":25788217,"casesPerOneMillion":1134,"deathsPerOneMillion":35,"testsPerOneMillion":581081.5}`))
+	}))
+	defer server.Close()
+	c := client.NewClient(server.URL + "/historical/%v?lastdays=%v")
+
+	buf := new(bytes.Buffer)
+	err := run_snapshot(context.Background(), c, []string{"australia"}, "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Country,Updated,Cases,Today Cases,Deaths,Today Deaths,Active,Critical,Tests,Population,Cases Per Million,Deaths Per Million,Tests Per Million\nAustralia,2021-03-26 00:00:00,29239,9,909,0,2020,1,14985005,25788217,1134,35,581081.5\n", buf.String())
+
+	buf = new(bytes.Buffer)
+	err = run_snapshot(context.Background(), c, []string{"azzz"}, "csv", buf)
+	assert.Error(err)
+	assert.Equal("", buf.String())
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"io"
+
+	"github.com/johnDorian/clatest/client"
+	"github.com/spf13/cobra"
+)
+
+var nyt = false
+
+// usStatesCmd gets the data for US states
+var usStatesCmd = &cobra.Command{
+	Use:   "us-states [state...]",
+	Short: "Get the latest stats on covid for US states",
+	Long: `Get the latest stats on covid for US states, or every state when none are
+given. The data is downloaded from disease.sh and is sourced from the New York 
+Times. Each argument is one state, so states with more than one word need to be 
+quoted (e.g. us-states "new york" texas) or comma separated.
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := interruptContext()
+		defer stop()
+		err := writeOutput(func(output io.Writer) error {
+			return run_us_states(ctx, newClient(RequestURI), splitNames(args), from, to, exact, format, output)
+		})
+		exitOnError(err)
+	},
+}
+
+// usCountiesCmd gets the data for the counties of a US state
+var usCountiesCmd = &cobra.Command{
+	Use:   "us-counties <state> [county...]",
+	Short: "Get the latest stats on covid for the counties of a US state",
+	Long: `Get the latest stats on covid for the counties of a US state, or every county
+of the state when none are given. The data is downloaded from disease.sh and is 
+sourced from John Hopkins (or the New York Times with --nyt). Each argument is one 
+state or county, so names with more than one word need to be quoted or comma 
+separated.
+	`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := interruptContext()
+		defer stop()
+		err := writeOutput(func(output io.Writer) error {
+			return run_us_counties(ctx, newClient(RequestURI), args[0], splitNames(args[1:]), from, to, exact, format, output)
+		})
+		exitOnError(err)
+	},
+}
+
+func init() {
+	usCountiesCmd.Flags().BoolVar(&nyt, "nyt", false, "Use the New York Times county data")
+	rootCmd.AddCommand(usStatesCmd)
+	rootCmd.AddCommand(usCountiesCmd)
+}
+
+func run_us_states(ctx context.Context, c *client.APIClient, states []string, from, to, exact, format string, output io.Writer) error {
+	fromDate, toDate, err := parseDates(from, to, exact)
+	if err != nil {
+		return err
+	}
+	series, err := c.USStatesContext(ctx, states, fromDate, toDate, latest)
+	if len(series) > 0 {
+		res := client.Combine(series...)
+		res.Print(output, format)
+	}
+	return warnBadDates(err)
+}
+
+func run_us_counties(ctx context.Context, c *client.APIClient, state string, counties []string, from, to, exact, format string, output io.Writer) error {
+	fromDate, toDate, err := parseDates(from, to, exact)
+	if err != nil {
+		return err
+	}
+	var series []client.TimeSeries
+	if nyt {
+		series, err = c.NYTCountiesContext(ctx, state, counties, fromDate, toDate, latest)
+	} else {
+		series, err = c.USCountiesContext(ctx, state, counties, fromDate, toDate, latest)
+	}
+	if len(series) > 0 {
+		res := client.Combine(series...)
+		res.Print(output, format)
+	}
+	return warnBadDates(err)
+}
@@ -0,0 +1,84 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/johnDorian/clatest/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunUS(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/nyt/states":
+			w.Write([]byte(`[{"date":"2021-03-25","state":"Washington","fips":"53","cases":354000,"deaths":5160},{"date":"2021-03-25","state":"New York","fips":"36","cases":1808000,"deaths":49560},{"date":"2021-03-25","state":"Texas","fips":"48","cases":2770000,"deaths":47750},{"date":"2021-03-25","state":"Florida","fips":"12","cases":2030000,"deaths":32900}]`))
+		case "/nyt/counties/King":
+			w.Write([]byte(`[{"date":"2021-03-25","county":"King","state":"Washington","fips":"53033","cases":86000,"deaths":1430}]`))
+		case "/historical/usacounties/washington":
+			w.Write([]byte(`[{"province":"washington","county":"king","timeline":{"cases":{"3/25/21":86000},"deaths":{"3/25/21":1430}}}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"State not found"}`))
+		}
+	}))
+	defer server.Close()
+	c := client.NewClient(server.URL + "/historical/%v?lastdays=%v")
+
+	buf := new(bytes.Buffer)
+	err := run_us_states(context.Background(), c, splitNames([]string{"new york", "washington"}), "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Province,Date,Cases,Deaths,Recovered\nNew York,2021-03-25,1808000,49560,0\nWashington,2021-03-25,354000,5160,0\n", buf.String())
+
+	buf = new(bytes.Buffer)
+	err = run_us_states(context.Background(), c, splitNames([]string{"texas", "florida"}), "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Province,Date,Cases,Deaths,Recovered\nTexas,2021-03-25,2770000,47750,0\nFlorida,2021-03-25,2030000,32900,0\n", buf.String())
+
+	buf = new(bytes.Buffer)
+	err = run_us_counties(context.Background(), c, "washington", splitNames(nil), "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Province,County,Date,Cases,Deaths,Recovered\nwashington,king,2021-03-25,86000,1430,0\n", buf.String())
+
+	// a state without data is not found
+	err = run_us_states(context.Background(), c, []string{"texas", "oregon"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", new(bytes.Buffer))
+	assert.EqualError(err, "oregon: no data found")
+	assert.Equal(exitNotFound, exitCode(err))
+	err = run_us_counties(context.Background(), c, "washington", []string{"Pierce"}, "2021-01-01", "2021-01-01", "2021-03-25", "csv", new(bytes.Buffer))
+	assert.EqualError(err, "Pierce: no data found")
+	assert.Equal(exitNotFound, exitCode(err))
+
+	nyt = true
+	defer func() { nyt = false }()
+	buf = new(bytes.Buffer)
+	err = run_us_counties(context.Background(), c, "washington", splitNames([]string{"King"}), "2021-01-01", "2021-01-01", "2021-03-25", "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Province,County,Date,Cases,Deaths,Recovered\nWashington,King,2021-03-25,86000,1430,0\n", buf.String())
+
+	assert.Equal([]string{"new york", "texas", "florida"}, splitNames([]string{"new york", "texas,florida"}))
+	assert.Nil(splitNames(nil))
+
+	err = run_us_states(context.Background(), c, nil, "bad", "2021-01-01", "", "csv", new(bytes.Buffer))
+	assert.Error(err)
+}
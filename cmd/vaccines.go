@@ -0,0 +1,107 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/johnDorian/clatest/client"
+	"github.com/spf13/cobra"
+)
+
+var joinVaccines = false
+
+// vaccinesCmd gets the vaccine doses given in a country
+var vaccinesCmd = &cobra.Command{
+	Use:   "vaccines <country>",
+	Short: "Get the total vaccine doses given in your country",
+	Long: `Get the total number of vaccine doses given in one or more countries. The data 
+is downloaded from disease.sh. Use --vaccines on the main command to add the doses
+as an extra column to the cases and deaths.
+	`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := interruptContext()
+		defer stop()
+		err := writeOutput(func(output io.Writer) error {
+			return run_vaccines(ctx, newClient(RequestURI), parseCountries(args), from, to, exact, format, output)
+		})
+		exitOnError(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(vaccinesCmd)
+}
+
+//vaccineSeries lets the vaccine doses be fetched like any other DataSource
+type vaccineSeries struct {
+	client.VaccineSource
+}
+
+func (v vaccineSeries) SeriesContext(ctx context.Context, country string, from, to time.Time, latest bool) (client.TimeSeries, error) {
+	return v.VaccinesContext(ctx, country, from, to, latest)
+}
+
+func run_vaccines(ctx context.Context, source client.VaccineSource, countries []string, from, to, exact, format string, output io.Writer) error {
+	fromDate, toDate, err := parseDates(from, to, exact)
+	if err != nil {
+		return err
+	}
+	// a source which also has the cases has the names of those countries
+	var named client.DataSource = vaccineSeries{source}
+	if dataSource, ok := source.(client.DataSource); ok {
+		named = dataSource
+	}
+	countries, failed := resolveCountries(named, countries)
+	series, err := client.FetchAllContext(ctx, vaccineSeries{source}, countries, fromDate, toDate, latest, workers)
+	if len(series) > 0 {
+		res := client.Combine(series...)
+		res.ShowCountry = len(countries)+len(failed) > 1
+		res.Print(output, format)
+	}
+	return warnBadDates(mergeErrors(failed, err))
+}
+
+//addVaccines joins the vaccine doses onto each of the series
+func addVaccines(ctx context.Context, source client.DataSource, series []client.TimeSeries) error {
+	vaccineSource, ok := source.(client.VaccineSource)
+	if !ok {
+		return fmt.Errorf("the data source doesn't have vaccine data")
+	}
+	for i, ts := range series {
+		if len(ts.Data) == 0 {
+			continue
+		}
+		first, last := ts.Data[0], ts.Data[len(ts.Data)-1]
+		vaccines, err := vaccineSource.VaccinesContext(ctx, first.Country, first.Date, last.Date, false)
+		if errors.Is(err, context.Canceled) {
+			return err
+		}
+		if err != nil {
+			err = warnBadDates(client.SeriesError{first.Country: err})
+		}
+		if err != nil {
+			return err
+		}
+		series[i].Join(vaccines)
+	}
+	return nil
+}
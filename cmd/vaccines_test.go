@@ -0,0 +1,83 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/johnDorian/clatest/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func vaccineServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.EqualFold(r.URL.Path, "/vaccine/coverage/countries/australia"):
+			w.Write([]byte(`{"country":"Australia","timeline":{"3/24/21":158000,"3/25/21":196000}}`))
+		case strings.HasPrefix(strings.ToLower(r.URL.Path), "/historical/australia"):
+			w.Write([]byte(responseData))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"country not found"}`))
+		}
+	}))
+}
+
+func TestRunVaccines(t *testing.T) {
+	assert := assert.New(t)
+	server := vaccineServer()
+	defer server.Close()
+	c := client.NewClient(server.URL + "/historical/%v?lastdays=%v")
+
+	buf := new(bytes.Buffer)
+	err := run_vaccines(context.Background(), c, []string{"australia"}, "2021-03-24", "2021-03-25", "", "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Date,Doses\n2021-03-24,158000\n2021-03-25,196000\n", buf.String())
+
+	buf = new(bytes.Buffer)
+	err = run_vaccines(context.Background(), c, []string{"australia", "azzz"}, "2021-03-24", "2021-03-25", "2021-03-25", "csv", buf)
+	assert.EqualError(err, "azzz: country not found")
+	assert.Equal("Country,Date,Doses\nAustralia,2021-03-25,196000\n", buf.String())
+}
+
+func TestRunCMDJoinVaccines(t *testing.T) {
+	assert := assert.New(t)
+	server := vaccineServer()
+	defer server.Close()
+	c := client.NewClient(server.URL + "/historical/%v?lastdays=%v")
+
+	joinVaccines = true
+	defer func() { joinVaccines = false }()
+
+	buf := new(bytes.Buffer)
+	err := run_cmd(context.Background(), c, []string{"australia"}, "2021-03-24", "2021-03-25", "", "csv", buf)
+	assert.NoError(err)
+	assert.Equal("Date,Cases,Deaths,Recovered,Doses\n2021-03-24,29230,909,22988,158000\n2021-03-25,29239,909,22991,196000\n", buf.String())
+
+	err = run_cmd(context.Background(), client.NewJHUSource("../client/testdata/jhu"), []string{"australia"}, "2021-03-24", "2021-03-25", "", "csv", new(bytes.Buffer))
+	assert.Error(err)
+
+	province = []string{"victoria"}
+	defer func() { province = nil }()
+	err = run_cmd(context.Background(), c, []string{"australia"}, "2021-03-24", "2021-03-25", "", "csv", new(bytes.Buffer))
+	assert.Error(err)
+}
@@ -0,0 +1,118 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/johnDorian/clatest/client"
+)
+
+var watch time.Duration
+
+func init() {
+	rootCmd.Flags().DurationVar(&watch, "watch", 0, "Query again every interval (e.g. 10m) and print only the days which are new or changed")
+}
+
+//today the date of the --to flag's default
+func today() time.Time {
+	t, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	return t
+}
+
+//newWatchSource the data source for --watch. Every poll has to ask the server, as a cached response younger
+//than --cache-ttl would hide the new data being watched for. The cache still sends a conditional request, so a
+//poll which finds nothing new doesn't download the data again
+func newWatchSource(name, RequestURI, path string) (client.DataSource, error) {
+	defer func(ttl time.Duration) { cacheTTL = ttl }(cacheTTL)
+	cacheTTL = 0
+	return newSource(name, RequestURI, path)
+}
+
+//run_watch queries the countries every interval until Ctrl-C, printing all the days the first time and only
+//the days which are new or changed after that. When only some countries fail the rest are still printed and
+//the failures are a warning. A query where every country failed is an error the first time and a warning after
+//that, and a country which failed is compared with the last time it succeeded
+func run_watch(ctx context.Context, source client.DataSource, countries []string, from, to, exact string, format string, interval time.Duration, output io.Writer) error {
+	fromDate, toDate, err := parseDates(from, to, exact)
+	if err != nil {
+		return err
+	}
+	// a window which ends today keeps ending today, so the days after midnight show up
+	followToday := exact == "" && !toDate.Before(today())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var prev client.TimeSeries
+	for polls := 0; ; polls++ {
+		if followToday {
+			toDate = today()
+		}
+		series, err := fetchSeries(ctx, source, countries, fromDate, toDate)
+		var failed client.SeriesError
+		partial := errors.As(err, &failed) && len(series) > 0
+		switch {
+		case errors.Is(err, context.Canceled):
+			return nil
+		case err != nil && !partial && polls == 0:
+			return err
+		case err != nil && !partial:
+			fmt.Fprintf(os.Stderr, "warning: %v\ntrying again in %v\n", err, interval)
+		default:
+			current := client.Combine(series...)
+			changed := current.Changed(prev)
+			changed.ShowCountry = manyPlaces(countries)
+			if len(changed.Data) > 0 {
+				changed.Print(output, format)
+			}
+			if partial {
+				fmt.Fprintf(os.Stderr, "warning: %v\ntrying again in %v\n", err, interval)
+			}
+			prev = carryOver(current, prev)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+//carryOver adds the days of prev for the places (countries, provinces or counties) which aren't in current, as
+//they failed this time. Their days then aren't printed again as new once they succeed
+func carryOver(current, prev client.TimeSeries) client.TimeSeries {
+	type place struct {
+		country, province, county string
+	}
+	seen := map[place]bool{}
+	for _, obs := range current.Data {
+		seen[place{obs.Country, obs.Province, obs.County}] = true
+	}
+	carried := client.TimeSeries{Data: append([]client.Day(nil), current.Data...)}
+	for _, obs := range prev.Data {
+		if !seen[place{obs.Country, obs.Province, obs.County}] {
+			carried.Data = append(carried.Data, obs)
+		}
+	}
+	return carried
+}
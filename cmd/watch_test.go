@@ -0,0 +1,159 @@
+/*
+Copyright © 2021 Jason Lessels <jlessels@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/johnDorian/clatest/client"
+	"github.com/stretchr/testify/assert"
+)
+
+//pollSource returns the next poll for a country each time the country is queried, and cancels the watch during
+//the last poll, like a Ctrl-C
+type pollSource struct {
+	mu     sync.Mutex
+	polls  []stubSource
+	calls  map[string]int
+	cancel context.CancelFunc
+}
+
+func (s *pollSource) SeriesContext(ctx context.Context, country string, from, to time.Time, latest bool) (client.TimeSeries, error) {
+	s.mu.Lock()
+	if s.calls == nil {
+		s.calls = map[string]int{}
+	}
+	poll := s.polls[s.calls[country]]
+	s.calls[country]++
+	if s.calls[country] == len(s.polls) {
+		s.cancel()
+	}
+	s.mu.Unlock()
+	return poll.SeriesContext(ctx, country, from, to, latest)
+}
+
+func TestRunWatch(t *testing.T) {
+	assert := assert.New(t)
+
+	to := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+	ctx, cancel := context.WithCancel(context.Background())
+	source := &pollSource{cancel: cancel, polls: []stubSource{
+		{days: map[string][]client.Day{"Australia": dailyCases("Australia", to.AddDate(0, 0, -1), 100, 110)}},
+		{errs: map[string]error{"Australia": fmt.Errorf("boom")}},
+		{days: map[string][]client.Day{"Australia": dailyCases("Australia", to.AddDate(0, 0, -1), 100, 110)}},
+		{days: map[string][]client.Day{"Australia": dailyCases("Australia", to, 100, 112, 120)}},
+		{days: map[string][]client.Day{"Australia": dailyCases("Australia", to, 100, 112, 130)}},
+	}}
+
+	buf := new(bytes.Buffer)
+	err := run_watch(ctx, source, []string{"Australia"}, "2021-03-20", "2021-03-25", "", "csv", time.Millisecond, buf)
+	assert.NoError(err)
+	assert.Equal(5, source.calls["Australia"])
+	assert.Equal("Date,Cases,Deaths,Recovered\n2021-03-23,100,1,0\n2021-03-24,110,1,0\n"+
+		"Date,Cases,Deaths,Recovered\n2021-03-24,112,1,0\n2021-03-25,120,1,0\n", buf.String())
+
+	// the first query failing is an error
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	failing := stubSource{errs: map[string]error{"Australia": client.ErrCountryNotFound}}
+	err = run_watch(ctx, failing, []string{"Australia"}, "2021-03-20", "2021-03-25", "", "csv", time.Millisecond, new(bytes.Buffer))
+	assert.Error(err)
+
+	err = run_watch(ctx, failing, []string{"Australia"}, "bad", "2021-03-25", "", "csv", time.Millisecond, new(bytes.Buffer))
+	assert.Error(err)
+}
+
+func TestRunWatchPartial(t *testing.T) {
+	assert := assert.New(t)
+
+	to := time.Date(2021, 3, 25, 0, 0, 0, 0, time.UTC)
+	australia := dailyCases("Australia", to, 100, 110)
+	newZealand := dailyCases("New Zealand", to, 20, 21)
+	ctx, cancel := context.WithCancel(context.Background())
+	source := &pollSource{cancel: cancel, polls: []stubSource{
+		// New Zealand failing doesn't hide Australia
+		{days: map[string][]client.Day{"Australia": australia}, errs: map[string]error{"New Zealand": fmt.Errorf("boom")}},
+		{days: map[string][]client.Day{"Australia": australia, "New Zealand": newZealand}},
+		// nor does Australia failing hide New Zealand's new day, and Australia isn't new again once it is back
+		{days: map[string][]client.Day{"New Zealand": dailyCases("New Zealand", to, 20, 25)}, errs: map[string]error{"Australia": fmt.Errorf("boom")}},
+		{days: map[string][]client.Day{"Australia": australia, "New Zealand": dailyCases("New Zealand", to, 20, 25)}},
+		{errs: map[string]error{"Australia": fmt.Errorf("boom"), "New Zealand": fmt.Errorf("boom")}},
+		{},
+	}}
+
+	buf := new(bytes.Buffer)
+	err := run_watch(ctx, source, []string{"Australia", "New Zealand"}, "2021-03-24", "2021-03-25", "", "csv", time.Millisecond, buf)
+	assert.NoError(err)
+	assert.Equal("Country,Date,Cases,Deaths,Recovered\nAustralia,2021-03-24,100,1,0\nAustralia,2021-03-25,110,1,0\n"+
+		"Country,Date,Cases,Deaths,Recovered\nNew Zealand,2021-03-24,20,0,0\nNew Zealand,2021-03-25,21,0,0\n"+
+		"Country,Date,Cases,Deaths,Recovered\nNew Zealand,2021-03-25,25,0,0\n", buf.String())
+	assert.Equal(6, source.calls["Australia"])
+
+	// only every country failing on the first query is an error
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	failing := stubSource{errs: map[string]error{"Australia": client.ErrCountryNotFound, "New Zealand": client.ErrCountryNotFound}}
+	err = run_watch(ctx, failing, []string{"Australia", "New Zealand"}, "2021-03-24", "2021-03-25", "", "csv", time.Millisecond, new(bytes.Buffer))
+	assert.True(errors.Is(err, client.ErrCountryNotFound))
+}
+
+func TestRunWatchSkipsCache(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "clatest-watch")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	defer os.Setenv("XDG_CACHE_HOME", os.Getenv("XDG_CACHE_HOME"))
+	os.Setenv("XDG_CACHE_HOME", dir)
+	defer func(old bool) { noCache = old }(noCache)
+	noCache = false
+
+	// a poll answered from the cache would never reach the second request, so the watch would only time out
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	requests, revalidated := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			revalidated++
+		}
+		if requests == 2 {
+			cancel()
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"country":"Australia","timeline":{"cases":{"3/25/21":29239},"deaths":{"3/25/21":909},"recovered":{}}}`))
+	}))
+	defer server.Close()
+
+	// a poll within --cache-ttl of the last still asks the server
+	source, err := newWatchSource("disease.sh", server.URL+"/historical/%v?lastdays=%v", "")
+	assert.NoError(err)
+	assert.Equal(client.DefaultCacheTTL, cacheTTL)
+	err = run_watch(ctx, source, []string{"Australia"}, "2021-03-25", "2021-03-25", "", "csv", time.Millisecond, new(bytes.Buffer))
+	assert.NoError(err)
+	assert.Equal(2, requests)
+	assert.Equal(1, revalidated)
+}